@@ -0,0 +1,257 @@
+package client
+
+import (
+	"block/server/handlers"
+	"block/struct/block"
+	"block/struct/signing"
+	"block/struct/wallet"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Chain returns every block of the node's chain, genesis first.
+func (c *Client) Chain(ctx context.Context) ([]*block.Block, error) {
+	var response struct {
+		Chain []*block.Block `json:"chain"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/chain", nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Chain, nil
+}
+
+// LatestBlocks returns the newest blocks, newest first.
+func (c *Client) LatestBlocks(ctx context.Context) ([]*block.Block, error) {
+	var blocks []*block.Block
+	if err := c.do(ctx, http.MethodGet, "/miner/blocks", nil, &blocks); err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// MerkleProof proves that a transaction is in a block.
+func (c *Client) MerkleProof(ctx context.Context, blockHash string, txID string) (*block.MerkleProof, error) {
+	var proof block.MerkleProof
+	path := fmt.Sprintf("/blocks/%s/proof/%s", url.PathEscape(blockHash), url.PathEscape(txID))
+	if err := c.do(ctx, http.MethodGet, path, nil, &proof); err != nil {
+		return nil, err
+	}
+	return &proof, nil
+}
+
+// Block returns the block at a height, or with a hash. Unknown blocks match
+// ErrNotFound.
+func (c *Client) Block(ctx context.Context, heightOrHash string) (*block.Block, error) {
+	var b block.Block
+	if err := c.do(ctx, http.MethodGet, "/blocks/"+url.PathEscape(heightOrHash), nil, &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (c *Client) Genesis(ctx context.Context) (*block.GenesisResponse, error) {
+	var genesis block.GenesisResponse
+	if err := c.do(ctx, http.MethodGet, "/genesis", nil, &genesis); err != nil {
+		return nil, err
+	}
+	return &genesis, nil
+}
+
+func (c *Client) Supply(ctx context.Context) (*block.SupplyInfo, error) {
+	var supply block.SupplyInfo
+	if err := c.do(ctx, http.MethodGet, "/supply", nil, &supply); err != nil {
+		return nil, err
+	}
+	return &supply, nil
+}
+
+func (c *Client) Reorgs(ctx context.Context) ([]*block.ReorgEvent, error) {
+	var response struct {
+		Reorgs []*block.ReorgEvent `json:"reorgs"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/reorgs", nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Reorgs, nil
+}
+
+// Balance returns the funds of an address. An address the chain has never
+// seen is reported as an *APIError.
+func (c *Client) Balance(ctx context.Context, blockchainAddress string) (*block.BalanceResponse, error) {
+	var balance block.BalanceResponse
+	path := "/balance?blockchainAddress=" + url.QueryEscape(blockchainAddress)
+	if err := c.do(ctx, http.MethodGet, path, nil, &balance); err != nil {
+		return nil, err
+	}
+	if balance.Error != "" {
+		return nil, &APIError{Method: http.MethodGet, Path: path, StatusCode: http.StatusOK, Message: balance.Error}
+	}
+	return &balance, nil
+}
+
+// Nonce returns the nonce the next transaction of an address must use.
+func (c *Client) Nonce(ctx context.Context, blockchainAddress string) (uint64, error) {
+	var response handlers.NonceResponse
+	path := fmt.Sprintf("/accounts/%s/nonce", url.PathEscape(blockchainAddress))
+	if err := c.do(ctx, http.MethodGet, path, nil, &response); err != nil {
+		return 0, err
+	}
+	return response.Nonce, nil
+}
+
+// History returns the transactions an address sent or received, pending ones
+// first, then confirmed ones newest first.
+func (c *Client) History(ctx context.Context, blockchainAddress string) ([]*block.TransactionLookup, error) {
+	var response handlers.HistoryResponse
+	path := fmt.Sprintf("/accounts/%s/transactions", url.PathEscape(blockchainAddress))
+	if err := c.do(ctx, http.MethodGet, path, nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Transactions, nil
+}
+
+// Transaction looks a transaction up by ID. Unknown IDs match ErrNotFound.
+func (c *Client) Transaction(ctx context.Context, id string) (*block.TransactionLookup, error) {
+	var lookup block.TransactionLookup
+	if err := c.do(ctx, http.MethodGet, "/tx/"+url.PathEscape(id), nil, &lookup); err != nil {
+		return nil, err
+	}
+	return &lookup, nil
+}
+
+// PendingTransactions returns the transactions in the node's pool.
+func (c *Client) PendingTransactions(ctx context.Context) ([]*block.Transaction, error) {
+	var response struct {
+		Transactions []*block.Transaction `json:"transactions"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/transactions", nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Transactions, nil
+}
+
+// SubmitTransaction adds a signed transaction to the node's pool and returns
+// its ID.
+func (c *Client) SubmitTransaction(ctx context.Context, st *signing.SignedTransaction) (string, error) {
+	var response struct {
+		ID string `json:"id"`
+	}
+	if err := c.do(ctx, http.MethodPut, "/transactions", st, &response); err != nil {
+		return "", err
+	}
+	return response.ID, nil
+}
+
+func (c *Client) Mempool(ctx context.Context) (*handlers.MempoolResponse, error) {
+	var mempool handlers.MempoolResponse
+	if err := c.do(ctx, http.MethodGet, "/mempool", nil, &mempool); err != nil {
+		return nil, err
+	}
+	return &mempool, nil
+}
+
+func (c *Client) FeeEstimate(ctx context.Context) (*block.FeeEstimate, error) {
+	var estimate block.FeeEstimate
+	if err := c.do(ctx, http.MethodGet, "/fees/estimate", nil, &estimate); err != nil {
+		return nil, err
+	}
+	return &estimate, nil
+}
+
+// Wallets returns the addresses registered on the chain.
+func (c *Client) Wallets(ctx context.Context) ([]string, error) {
+	var response struct {
+		Wallets []string `json:"wallets"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/wallets", nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Wallets, nil
+}
+
+// RegisterWallet records the address of a public key on the chain.
+func (c *Client) RegisterWallet(ctx context.Context, publicKey string) (*handlers.RegisterWalletResponse, error) {
+	var response handlers.RegisterWalletResponse
+	request := map[string]string{"publicKey": publicKey}
+	if err := c.do(ctx, http.MethodPost, "/wallet/register", request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// NextAddress derives the next unused receiving address of an HD wallet from
+// its extended public key, starting at index from.
+func (c *Client) NextAddress(ctx context.Context, extendedPublicKey string, from uint32) (*wallet.HDAddress, error) {
+	var next wallet.HDAddress
+	path := fmt.Sprintf("/wallet/next?extendedPublicKey=%s&from=%s",
+		url.QueryEscape(extendedPublicKey), strconv.FormatUint(uint64(from), 10))
+	if err := c.do(ctx, http.MethodGet, path, nil, &next); err != nil {
+		return nil, err
+	}
+	return &next, nil
+}
+
+// Nodes returns the node's neighbors.
+func (c *Client) Nodes(ctx context.Context) ([]string, error) {
+	var response struct {
+		Nodes []string `json:"nodes"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/nodes", nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Nodes, nil
+}
+
+// Consensus asks the node to adopt a better neighbor chain and reports
+// whether it did.
+func (c *Client) Consensus(ctx context.Context) (bool, error) {
+	var response struct {
+		Message string `json:"message"`
+	}
+	if err := c.do(ctx, http.MethodPut, "/consensus", nil, &response); err != nil {
+		return false, err
+	}
+	return response.Message == "success", nil
+}
+
+// MinerWallet returns the address the node mines to.
+func (c *Client) MinerWallet(ctx context.Context) (*handlers.MinerWalletResponse, error) {
+	var response handlers.MinerWalletResponse
+	if err := c.do(ctx, http.MethodGet, "/miner/wallet", nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Mine mines one block paying minerAddress and returns its height, hash
+// and coinbase.
+func (c *Client) Mine(ctx context.Context, minerAddress string) (*handlers.MineResponse, error) {
+	var response handlers.MineResponse
+	request := handlers.MineRequest{MinerAddress: minerAddress}
+	if err := c.do(ctx, http.MethodPost, "/mine", request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// StartMining starts the node's mining loop.
+func (c *Client) StartMining(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/mine/start", nil, nil)
+}
+
+// StopMining stops the node's mining loop.
+func (c *Client) StopMining(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/mine/stop", nil, nil)
+}
+
+// Mining reports whether the node's mining loop is running.
+func (c *Client) Mining(ctx context.Context) (bool, error) {
+	var response handlers.MiningStatusResponse
+	if err := c.do(ctx, http.MethodGet, "/mine/status", nil, &response); err != nil {
+		return false, err
+	}
+	return response.Mining, nil
+}
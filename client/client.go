@@ -0,0 +1,204 @@
+// Package client talks to a node's REST API. Methods take a context, decode
+// responses into the node's own types and report failures as *APIError.
+// Reads are retried on network errors and 5xx responses; writes are sent
+// once.
+//
+//	c := client.NewClient("http://localhost:5001")
+//	balance, err := c.Balance(ctx, address)
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Client defaults.
+const (
+	DEFAULT_TIMEOUT_SEC = 30
+	DEFAULT_RETRIES     = 3
+	DEFAULT_BACKOFF     = 200 * time.Millisecond
+	MAX_BACKOFF         = 5 * time.Second
+)
+
+var (
+	ErrBadRequest = errors.New("bad request")
+	ErrForbidden  = errors.New("forbidden")
+	ErrNotFound   = errors.New("not found")
+	ErrServer     = errors.New("node error")
+)
+
+// APIError is a request the node refused or failed. It matches ErrBadRequest,
+// ErrForbidden, ErrNotFound or ErrServer with errors.Is. Field and Reason are
+// set when an address in the request was malformed.
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Message    string
+	Field      string
+	Reason     string
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	return fmt.Sprintf("%s %s: %d: %s", e.Method, e.Path, e.StatusCode, msg)
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusMethodNotAllowed
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrServer:
+		return e.StatusCode >= http.StatusInternalServerError
+	}
+	return false
+}
+
+// Client is a client of one node.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	retries    int
+	backoff    time.Duration
+}
+
+// NewClient returns a client of the node at baseURL, such as
+// "http://localhost:5001".
+func NewClient(baseURL string) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: DEFAULT_TIMEOUT_SEC * time.Second},
+		retries:    DEFAULT_RETRIES,
+		backoff:    DEFAULT_BACKOFF,
+	}
+}
+
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// SetHTTPClient replaces the HTTP client, for example to change timeouts or
+// to talk to an in-process server.
+func (c *Client) SetHTTPClient(httpClient *http.Client) {
+	c.httpClient = httpClient
+}
+
+// SetRetries sets how often a failed read is retried and the first delay,
+// which doubles on each attempt.
+func (c *Client) SetRetries(retries int, backoff time.Duration) {
+	c.retries = retries
+	c.backoff = backoff
+}
+
+func retryable(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+// do sends a request and decodes a JSON response into out, which may be nil.
+func (c *Client) do(ctx context.Context, method string, path string, in interface{}, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+
+	attempts := 1
+	if retryable(method) {
+		attempts += c.retries
+	}
+	delay := c.backoff
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+			if delay *= 2; delay > MAX_BACKOFF {
+				delay = MAX_BACKOFF
+			}
+		}
+
+		var retry bool
+		retry, err = c.try(ctx, method, path, body, out)
+		if err == nil || !retry || ctx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// try makes one attempt and reports whether a failure is worth retrying.
+func (c *Client) try(ctx context.Context, method string, path string, body []byte, out interface{}) (bool, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return false, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return true, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode >= http.StatusInternalServerError, newAPIError(method, path, resp.StatusCode, data)
+	}
+	if out == nil {
+		return false, nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return false, fmt.Errorf("%s %s: failed to decode response: %v", method, path, err)
+	}
+	return false, nil
+}
+
+// newAPIError reads the message from the error bodies handlers write:
+// {"message"}, {"error"} or, for bad addresses, {"error", "field", "reason"}.
+func newAPIError(method string, path string, status int, data []byte) *APIError {
+	e := &APIError{Method: method, Path: path, StatusCode: status}
+	var body struct {
+		Message string `json:"message"`
+		Error   string `json:"error"`
+		Field   string `json:"field"`
+		Reason  string `json:"reason"`
+	}
+	if json.Unmarshal(data, &body) == nil {
+		e.Message, e.Field, e.Reason = body.Message, body.Field, body.Reason
+		if body.Error != "" {
+			e.Message = body.Error
+		}
+	} else {
+		e.Message = strings.TrimSpace(string(data))
+	}
+	return e
+}
@@ -0,0 +1,310 @@
+package client
+
+import (
+	"block/server/handlers"
+	"block/struct/address"
+	"block/struct/amount"
+	"block/struct/block"
+	"block/struct/config"
+	"block/struct/utils"
+	"block/struct/wallet"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testServer is a node without networking, persistence or a mining loop.
+type testServer struct {
+	blockchain *block.Blockchain
+	wallet     *wallet.Wallet
+	config     *config.Config
+}
+
+func (s *testServer) Port() uint16                     { return s.config.Port }
+func (s *testServer) GetWallet() *wallet.Wallet        { return s.wallet }
+func (s *testServer) GetBlockchain() *block.Blockchain { return s.blockchain }
+func (s *testServer) Config() *config.Config           { return s.config }
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return privateKey
+}
+
+// newTestNode serves a fresh chain whose genesis gives funded 10 coins. The
+// difficulty is kept low so blocks are mined at once.
+func newTestNode(t *testing.T, funded string) (*Client, *testServer) {
+	t.Helper()
+	g := block.DefaultGenesis()
+	g.Params.InitialDifficulty, g.Params.MinDifficulty, g.Params.MaxDifficulty = 4, 4, 6
+	g.Allocations = []block.Allocation{{Address: funded, Amount: amount.MustFromCoins(10)}}
+
+	minerWallet := wallet.NewWallet()
+	cfg := config.Default()
+	s := &testServer{
+		blockchain: block.NewBlockchain(minerWallet.BlockchainAddress(), cfg, g),
+		wallet:     minerWallet,
+		config:     cfg,
+	}
+	ts := httptest.NewServer(handlers.NewRouter(s))
+	t.Cleanup(ts.Close)
+
+	c := NewClient(ts.URL)
+	c.SetRetries(0, 0)
+	return c, s
+}
+
+func TestChain(t *testing.T) {
+	c, s := newTestNode(t, address.FromPublicKey(&newKey(t).PublicKey))
+	ctx := context.Background()
+
+	chain, err := c.Chain(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chain) != 1 || chain[0].GetHeight() != 0 || chain[0].GetHash() != s.blockchain.GenesisHash() {
+		t.Fatalf("Chain returned %d blocks, want the genesis block %s", len(chain), s.blockchain.GenesisHash())
+	}
+	genesis, err := c.Block(ctx, "0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if genesis.GetHash() != chain[0].GetHash() {
+		t.Fatalf("Block(0) = %s, want %s", genesis.GetHash(), chain[0].GetHash())
+	}
+}
+
+func TestBalance(t *testing.T) {
+	funded := address.FromPublicKey(&newKey(t).PublicKey)
+	c, _ := newTestNode(t, funded)
+	ctx := context.Background()
+
+	b, err := c.Balance(ctx, funded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Balance != amount.MustFromCoins(10) || b.Immature != 0 || b.PendingIncoming != 0 || b.PendingOutgoing != 0 {
+		t.Fatalf("Balance = %+v, want 10 spendable coins", b)
+	}
+
+	unknown := address.FromPublicKey(&newKey(t).PublicKey)
+	_, err = c.Balance(ctx, unknown)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message == "" {
+		t.Fatalf("Balance of an unknown address error = %v, want an *APIError", err)
+	}
+
+	_, err = c.Balance(ctx, "not-an-address")
+	if !errors.As(err, &apiErr) || !errors.Is(err, ErrBadRequest) || apiErr.Field != "blockchainAddress" {
+		t.Fatalf("Balance of a malformed address error = %v, want ErrBadRequest on blockchainAddress", err)
+	}
+}
+
+func TestSignedTransferAndMine(t *testing.T) {
+	sender := newKey(t)
+	from := address.FromPublicKey(&sender.PublicKey)
+	to := address.FromPublicKey(&newKey(t).PublicKey)
+	c, s := newTestNode(t, from)
+	ctx := context.Background()
+
+	nonce, err := c.Nonce(ctx, from)
+	if err != nil || nonce != 0 {
+		t.Fatalf("Nonce = %d, %v, want 0", nonce, err)
+	}
+
+	// Build, sign locally and submit: only the public key and signature
+	// reach the node
+	p, err := c.BuildTransaction(ctx, from, to, amount.MustFromCoins(2), 1000, "rent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ChainID != s.blockchain.Genesis().ChainID || p.Nonce != 0 {
+		t.Fatalf("BuildTransaction = %+v, want the node's chain ID and nonce 0", p)
+	}
+	id, err := c.SignAndSubmit(ctx, sender, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if nonce, err = c.Nonce(ctx, from); err != nil || nonce != 1 {
+		t.Fatalf("Nonce after submitting = %d, %v, want 1", nonce, err)
+	}
+	pending, err := c.PendingTransactions(ctx)
+	if err != nil || len(pending) != 1 || pending[0].ID() != id {
+		t.Fatalf("PendingTransactions = %d transactions, %v, want %s", len(pending), err, id)
+	}
+	lookup, err := c.Transaction(ctx, id)
+	if err != nil || lookup.Status != "pending" {
+		t.Fatalf("Transaction(%s) = %+v, %v, want it pending", id, lookup, err)
+	}
+
+	// Replaying the same nonce is refused
+	if _, err := c.SignAndSubmit(ctx, sender, p); !errors.Is(err, ErrBadRequest) {
+		t.Fatalf("resubmitting nonce 0 error = %v, want ErrBadRequest", err)
+	}
+
+	miner := address.FromPublicKey(&newKey(t).PublicKey)
+	mined, err := c.Mine(ctx, miner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subsidy := s.blockchain.Params().BlockSubsidy(1, amount.MustFromCoins(10))
+	if mined.Height != 1 || mined.Reward != subsidy+1000 {
+		t.Fatalf("Mine = %+v, want height 1 and reward %s", mined, subsidy+1000)
+	}
+	b, err := c.Block(ctx, mined.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if coinbase := b.Coinbase(); coinbase == nil || coinbase.GetRecipient() != miner {
+		t.Fatalf("block %s does not pay its coinbase to %s", mined.Hash, miner)
+	}
+
+	lookup, err = c.Transaction(ctx, id)
+	if err != nil || lookup.Status != "confirmed" || lookup.BlockHash != mined.Hash {
+		t.Fatalf("Transaction(%s) = %+v, %v, want it confirmed in %s", id, lookup, err, mined.Hash)
+	}
+	balance, err := c.Balance(ctx, to)
+	if err != nil || balance.Balance != amount.MustFromCoins(2) {
+		t.Fatalf("Balance of the recipient = %+v, %v, want 2 coins", balance, err)
+	}
+	balance, err = c.Balance(ctx, from)
+	if want := amount.MustFromCoins(8) - 1000; err != nil || balance.Balance != want {
+		t.Fatalf("Balance of the sender = %+v, %v, want %s", balance, err, want)
+	}
+
+	// Send fills in the next nonce itself
+	if _, err := c.Send(ctx, sender, to, amount.MustFromCoins(1), 0, ""); err != nil {
+		t.Fatal(err)
+	}
+	if nonce, err = c.Nonce(ctx, from); err != nil || nonce != 2 {
+		t.Fatalf("Nonce after Send = %d, %v, want 2", nonce, err)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	c, _ := newTestNode(t, address.FromPublicKey(&newKey(t).PublicKey))
+	ctx := context.Background()
+
+	if _, err := c.Transaction(ctx, strings.Repeat("0", 64)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Transaction of an unknown ID error = %v, want ErrNotFound", err)
+	}
+	if _, err := c.Block(ctx, "99"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Block of an unknown height error = %v, want ErrNotFound", err)
+	}
+	if _, err := c.Block(ctx, strings.Repeat("f", 64)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Block of an unknown hash error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if calls.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"chainId":"retried"}`))
+	}))
+	defer ts.Close()
+	c := NewClient(ts.URL)
+	c.SetRetries(3, time.Millisecond)
+	ctx := context.Background()
+
+	chainID, err := c.ChainID(ctx)
+	if err != nil || chainID != "retried" {
+		t.Fatalf("ChainID = %q, %v, want the answer after two 503s", chainID, err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("GET was sent %d times, want 3", n)
+	}
+
+	// Writes are sent once
+	calls.Store(-10)
+	if _, err := c.Mine(ctx, "miner"); !errors.Is(err, ErrServer) {
+		t.Fatalf("Mine error = %v, want ErrServer", err)
+	}
+	if n := calls.Load(); n != -9 {
+		t.Fatalf("POST was sent %d times, want once", n+10)
+	}
+
+	// Reads give up after the configured retries
+	calls.Store(-10)
+	if _, err := c.ChainID(ctx); !errors.Is(err, ErrServer) {
+		t.Fatalf("ChainID error = %v, want ErrServer", err)
+	}
+	if n := calls.Load(); n != -6 {
+		t.Fatalf("GET was sent %d times, want 4", n+10)
+	}
+}
+
+func TestContextCancellation(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/genesis" {
+			select {
+			case <-req.Context().Done():
+			case <-release:
+			}
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+	defer close(release)
+	c := NewClient(ts.URL)
+	c.SetRetries(5, time.Minute)
+
+	// A request in flight is abandoned
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := c.Genesis(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Genesis error = %v, want context.DeadlineExceeded", err)
+	}
+
+	// So is the wait between retries
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if _, err := c.Chain(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Chain error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("cancelled requests took %s", elapsed)
+	}
+}
+
+func TestKeyEndpointsDisabled(t *testing.T) {
+	c, s := newTestNode(t, address.FromPublicKey(&newKey(t).PublicKey))
+	s.config.DisableKeyEndpoints = true
+	ctx := context.Background()
+
+	// The node refuses to generate a key as it refuses the other key endpoints
+	if err := c.do(ctx, http.MethodPost, "/wallet/register", map[string]string{}, nil); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("registering without a public key error = %v, want ErrForbidden", err)
+	}
+	if err := c.do(ctx, http.MethodPost, "/sign", map[string]string{}, nil); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("signing on the node error = %v, want ErrForbidden", err)
+	}
+
+	// Keys held by the client can still be registered
+	publicKey := &newKey(t).PublicKey
+	registered, err := c.RegisterWallet(ctx, utils.PublicKeyString(publicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if registered.Address != address.FromPublicKey(publicKey) || registered.PrivateKey != "" {
+		t.Fatalf("RegisterWallet = %+v, want the key's address and no private key", registered)
+	}
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"block/struct/address"
+	"block/struct/amount"
+	"block/struct/signing"
+	"context"
+	"crypto/ecdsa"
+)
+
+// ChainID returns the chain ID of the node.
+func (c *Client) ChainID(ctx context.Context) (string, error) {
+	genesis, err := c.Genesis(ctx)
+	if err != nil {
+		return "", err
+	}
+	return genesis.ChainID, nil
+}
+
+// BuildTransaction returns the payload of a transfer with the node's chain ID
+// and the sender's next nonce filled in, ready to sign.
+func (c *Client) BuildTransaction(ctx context.Context, sender string, recipient string, value amount.Amount, fee amount.Amount, message string) (*signing.Payload, error) {
+	chainID, err := c.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := c.Nonce(ctx, sender)
+	if err != nil {
+		return nil, err
+	}
+	p := &signing.Payload{
+		ChainID:   chainID,
+		Sender:    sender,
+		Recipient: recipient,
+		Value:     value,
+		Fee:       fee,
+		Nonce:     nonce,
+		Message:   message,
+	}
+	if err := p.Check(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// SignAndSubmit signs a payload locally and submits it. Only the public key
+// and signature are sent.
+func (c *Client) SignAndSubmit(ctx context.Context, privateKey *ecdsa.PrivateKey, p *signing.Payload) (string, error) {
+	st, err := signing.SignTransaction(privateKey, p)
+	if err != nil {
+		return "", err
+	}
+	return c.SubmitTransaction(ctx, st)
+}
+
+// Send transfers value from the address of privateKey to recipient, signing
+// locally, and returns the transaction ID.
+func (c *Client) Send(ctx context.Context, privateKey *ecdsa.PrivateKey, recipient string, value amount.Amount, fee amount.Amount, message string) (string, error) {
+	p, err := c.BuildTransaction(ctx, address.FromPublicKey(&privateKey.PublicKey), recipient, value, fee, message)
+	if err != nil {
+		return "", err
+	}
+	return c.SignAndSubmit(ctx, privateKey, p)
+}
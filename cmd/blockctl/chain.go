@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"text/tabwriter"
+	"time"
+)
+
+func balance(ctx context.Context, args []string) {
+	b, err := node.Balance(ctx, oneArg(args, "an address"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	render(b, func(w *tabwriter.Writer) {
+		fmt.Fprintf(w, "Balance:\t%s\n", b.Balance)
+		fmt.Fprintf(w, "Immature:\t%s\n", b.Immature)
+		fmt.Fprintf(w, "Pending incoming:\t%s\n", b.PendingIncoming)
+		fmt.Fprintf(w, "Pending outgoing:\t%s\n", b.PendingOutgoing)
+	})
+}
+
+func history(ctx context.Context, args []string) {
+	lookups, err := node.History(ctx, oneArg(args, "an address"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	render(lookups, func(w *tabwriter.Writer) {
+		printLookups(w, lookups)
+	})
+}
+
+func formatTime(timestamp int64) string {
+	return time.Unix(timestamp, 0).UTC().Format(time.RFC3339)
+}
+
+func chain(ctx context.Context) {
+	blocks, err := node.Chain(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	render(blocks, func(w *tabwriter.Writer) {
+		fmt.Fprintln(w, "HEIGHT\tHASH\tTIME\tTXS\tDIFFICULTY")
+		for _, b := range blocks {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d\n",
+				b.GetHeight(), shortHash(b.GetHash()), formatTime(b.GetTimestamp()), len(b.GetTransactions()), b.GetDifficulty())
+		}
+	})
+}
+
+func showBlock(ctx context.Context, args []string) {
+	b, err := node.Block(ctx, oneArg(args, "a block height or hash"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	render(b, func(w *tabwriter.Writer) {
+		fmt.Fprintf(w, "Height:\t%d\n", b.GetHeight())
+		fmt.Fprintf(w, "Hash:\t%s\n", b.GetHash())
+		fmt.Fprintf(w, "Previous:\t%s\n", b.GetPrevHash())
+		fmt.Fprintf(w, "Merkle root:\t%s\n", b.GetMerkleRoot())
+		fmt.Fprintf(w, "Time:\t%s\n", formatTime(b.GetTimestamp()))
+		fmt.Fprintf(w, "Difficulty:\t%d\n", b.GetDifficulty())
+		fmt.Fprintf(w, "Nonce:\t%d\n", b.GetNonce())
+		fmt.Fprintln(w)
+		printTransactions(w, b.GetTransactions())
+	})
+}
+
+func mempool(ctx context.Context) {
+	m, err := node.Mempool(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	render(m, func(w *tabwriter.Writer) {
+		fmt.Fprintf(w, "Transactions:\t%d of %d\n", m.Count, m.MaxCount)
+		fmt.Fprintf(w, "Bytes:\t%d of %d\n", m.Bytes, m.MaxBytes)
+		fmt.Fprintln(w)
+		printTransactions(w, m.Transactions)
+	})
+}
+
+func peers(ctx context.Context) {
+	nodes, err := node.Nodes(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	render(nodes, func(w *tabwriter.Writer) {
+		fmt.Fprintln(w, "PEER")
+		for _, n := range nodes {
+			fmt.Fprintln(w, n)
+		}
+	})
+}
@@ -0,0 +1,194 @@
+// Command blockctl drives a node from the command line: wallets, signed
+// transactions, balances and history, chain and block inspection, the
+// mempool, peers, mining and consensus.
+//
+//	go run ./cmd/blockctl wallet create
+//	go run ./cmd/blockctl tx send -from 1Abc... -to 1Def... -value 5
+//	go run ./cmd/blockctl -output json block 12
+//	go run ./cmd/blockctl -node http://10.0.0.2:5002 mine start
+//
+// The node URL comes from -node, then BLOCKCTL_NODE. Keys stay in the local
+// keystore and transactions are signed on this machine. The keystore password
+// is read from -password-file, then KEYSTORE_PASSWORD, then a line of standard
+// input.
+package main
+
+import (
+	"block/client"
+	"block/struct/keystore"
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	DEFAULT_NODE    = "http://localhost:5001"
+	OUTPUT_TABLE    = "table"
+	OUTPUT_JSON     = "json"
+	REQUEST_TIMEOUT = 30 * time.Second
+)
+
+const usageText = `usage: blockctl [flags] command [args]
+
+commands:
+  wallet create|list|import|export   manage keys in the local keystore
+  tx build|sign|submit|send|get      build, sign and submit transactions
+  balance ADDRESS                    funds of an address
+  history ADDRESS                    transactions of an address
+  chain                              blocks of the chain
+  block HEIGHT|HASH                  one block and its transactions
+  mempool                            pending transactions
+  peers                              neighbor nodes
+  mine start|stop|status|once        control mining
+  consensus                          adopt the best neighbor chain
+
+flags:
+`
+
+var (
+	node         *client.Client
+	ks           *keystore.Keystore
+	output       string
+	passwordFile string
+	stdin        = bufio.NewReader(os.Stdin)
+)
+
+func init() {
+	log.SetPrefix("blockctl: ")
+	log.SetFlags(0)
+}
+
+func usage() {
+	fmt.Fprint(os.Stderr, usageText)
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+func main() {
+	defaultNode := os.Getenv("BLOCKCTL_NODE")
+	if defaultNode == "" {
+		defaultNode = DEFAULT_NODE
+	}
+	nodeURL := flag.String("node", defaultNode, "node URL")
+	dir := flag.String("keystore", keystore.DEFAULT_DIR, "keystore directory")
+	flag.StringVar(&output, "output", OUTPUT_TABLE, "output format, table or json")
+	flag.StringVar(&passwordFile, "password-file", "", "file holding the keystore password")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+	}
+	if output != OUTPUT_TABLE && output != OUTPUT_JSON {
+		log.Fatalf("unknown output format %q", output)
+	}
+
+	node = client.NewClient(*nodeURL)
+	ks = keystore.NewKeystore(*dir)
+
+	ctx, cancel := context.WithTimeout(context.Background(), REQUEST_TIMEOUT)
+	defer cancel()
+
+	args := flag.Args()[1:]
+	switch flag.Arg(0) {
+	case "wallet":
+		walletCommand(ctx, args)
+	case "tx":
+		txCommand(ctx, args)
+	case "balance":
+		balance(ctx, args)
+	case "history":
+		history(ctx, args)
+	case "chain":
+		chain(ctx)
+	case "block":
+		showBlock(ctx, args)
+	case "mempool":
+		mempool(ctx)
+	case "peers":
+		peers(ctx)
+	case "mine":
+		mineCommand(ctx, args)
+	case "consensus":
+		consensus(ctx)
+	default:
+		usage()
+	}
+}
+
+// render prints v as JSON, or calls table with a tab-aligned writer.
+func render(v interface{}, table func(w *tabwriter.Writer)) {
+	if output == OUTPUT_JSON {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	table(w)
+	w.Flush()
+}
+
+// readLine prompts on standard error and reads a line of standard input.
+func readLine(prompt string) string {
+	fmt.Fprint(os.Stderr, prompt)
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		log.Fatalf("failed to read %s: %v", strings.TrimSuffix(prompt, ": "), err)
+	}
+	return strings.TrimRight(line, "\r\n")
+}
+
+func readPassword() string {
+	if passwordFile != "" {
+		data, err := os.ReadFile(passwordFile)
+		if err != nil {
+			log.Fatalf("failed to read password file: %v", err)
+		}
+		return strings.TrimRight(string(data), "\r\n")
+	}
+	if password, ok := os.LookupEnv("KEYSTORE_PASSWORD"); ok {
+		return password
+	}
+	return readLine("Password: ")
+}
+
+// readInput returns the contents of file, or standard input when file is
+// empty or "-".
+func readInput(file string) []byte {
+	var data []byte
+	var err error
+	if file == "" || file == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	return data
+}
+
+// oneArg returns the single argument of a command.
+func oneArg(args []string, what string) string {
+	if len(args) != 1 {
+		log.Fatalf("expected %s", what)
+	}
+	return args[0]
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 16 {
+		return hash[:16]
+	}
+	return hash
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"block/struct/amount"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"text/tabwriter"
+)
+
+type miningOutput struct {
+	Mining bool `json:"mining"`
+}
+
+type mineOnceOutput struct {
+	MinerAddress string        `json:"minerAddress"`
+	Height       uint64        `json:"height"`
+	Hash         string        `json:"hash"`
+	Reward       amount.Amount `json:"reward"`
+}
+
+func mineCommand(ctx context.Context, args []string) {
+	if len(args) == 0 {
+		log.Fatal("usage: blockctl mine start|stop|status|once")
+	}
+	switch args[0] {
+	case "start":
+		if err := node.StartMining(ctx); err != nil {
+			log.Fatal(err)
+		}
+		mineStatus(ctx)
+	case "stop":
+		if err := node.StopMining(ctx); err != nil {
+			log.Fatal(err)
+		}
+		mineStatus(ctx)
+	case "status":
+		mineStatus(ctx)
+	case "once":
+		mineOnce(ctx, args[1:])
+	default:
+		log.Fatalf("unknown mine command %q", args[0])
+	}
+}
+
+func mineStatus(ctx context.Context) {
+	mining, err := node.Mining(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	render(miningOutput{Mining: mining}, func(w *tabwriter.Writer) {
+		fmt.Fprintf(w, "Mining:\t%t\n", mining)
+	})
+}
+
+func mineOnce(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("mine once", flag.ExitOnError)
+	minerAddress := fs.String("address", "", "address paid the reward, the node's miner address by default")
+	fs.Parse(args)
+
+	if *minerAddress == "" {
+		mw, err := node.MinerWallet(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		*minerAddress = mw.BlockchainAddress
+	}
+	mined, err := node.Mine(ctx, *minerAddress)
+	if err != nil {
+		log.Fatal(err)
+	}
+	out := mineOnceOutput{MinerAddress: *minerAddress, Height: mined.Height, Hash: mined.Hash, Reward: mined.Reward}
+	render(out, func(w *tabwriter.Writer) {
+		fmt.Fprintf(w, "Mined to:\t%s\n", out.MinerAddress)
+		fmt.Fprintf(w, "Block:\t%d %s\n", out.Height, out.Hash)
+		fmt.Fprintf(w, "Reward:\t%s\n", out.Reward)
+	})
+}
+
+type consensusOutput struct {
+	Replaced bool `json:"replaced"`
+}
+
+func consensus(ctx context.Context) {
+	replaced, err := node.Consensus(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	render(consensusOutput{Replaced: replaced}, func(w *tabwriter.Writer) {
+		fmt.Fprintf(w, "Chain replaced:\t%t\n", replaced)
+	})
+}
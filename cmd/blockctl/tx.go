@@ -0,0 +1,183 @@
+package main
+
+import (
+	"block/struct/amount"
+	"block/struct/block"
+	"block/struct/signing"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"text/tabwriter"
+)
+
+type txIDOutput struct {
+	ID string `json:"id"`
+}
+
+// txCommand handles tx. build and sign print JSON whatever the output
+// format, so their output can be saved and passed to the next step.
+func txCommand(ctx context.Context, args []string) {
+	if len(args) == 0 {
+		log.Fatal("usage: blockctl tx build|sign|submit|send|get")
+	}
+	switch args[0] {
+	case "build":
+		printJSON(buildPayload(ctx, "tx build", args[1:]))
+	case "sign":
+		txSign(args[1:])
+	case "submit":
+		txSubmit(ctx, args[1:])
+	case "send":
+		txSend(ctx, args[1:])
+	case "get":
+		txGet(ctx, args[1:])
+	default:
+		log.Fatalf("unknown tx command %q", args[0])
+	}
+}
+
+func printJSON(v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(data))
+}
+
+// buildPayload reads the transfer flags. The chain ID and nonce are fetched
+// from the node unless both are given.
+func buildPayload(ctx context.Context, name string, args []string) *signing.Payload {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	from := fs.String("from", "", "sender address")
+	to := fs.String("to", "", "recipient address")
+	value := fs.String("value", "", "amount to send")
+	fee := fs.String("fee", "0", "fee for the miner")
+	message := fs.String("message", "", "message stored with the transaction")
+	chainID := fs.String("chain-id", "", "chain to sign for")
+	nonce := fs.Int64("nonce", -1, "sender nonce")
+	fs.Parse(args)
+
+	p := &signing.Payload{ChainID: *chainID, Sender: *from, Recipient: *to, Message: *message}
+	var err error
+	if p.Value, err = amount.Parse(*value); err != nil {
+		log.Fatalf("invalid value: %v", err)
+	}
+	if p.Fee, err = amount.Parse(*fee); err != nil {
+		log.Fatalf("invalid fee: %v", err)
+	}
+	if p.ChainID == "" {
+		if p.ChainID, err = node.ChainID(ctx); err != nil {
+			log.Fatalf("failed to fetch chain ID: %v", err)
+		}
+	}
+	if *nonce < 0 {
+		if p.Nonce, err = node.Nonce(ctx, p.Sender); err != nil {
+			log.Fatalf("failed to fetch nonce: %v", err)
+		}
+	} else {
+		p.Nonce = uint64(*nonce)
+	}
+	if err := p.Check(); err != nil {
+		log.Fatal(err)
+	}
+	return p
+}
+
+func signPayload(p *signing.Payload) *signing.SignedTransaction {
+	privateKey, err := ks.Unlock(p.Sender, readPassword())
+	if err != nil {
+		log.Fatal(err)
+	}
+	st, err := signing.SignTransaction(privateKey, p)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return st
+}
+
+func submit(ctx context.Context, st *signing.SignedTransaction) {
+	id, err := node.SubmitTransaction(ctx, st)
+	if err != nil {
+		log.Fatal(err)
+	}
+	render(txIDOutput{ID: id}, func(w *tabwriter.Writer) {
+		fmt.Fprintf(w, "Submitted:\t%s\n", id)
+	})
+}
+
+func txSign(args []string) {
+	fs := flag.NewFlagSet("tx sign", flag.ExitOnError)
+	in := fs.String("in", "", "unsigned transaction from tx build, - or empty for standard input")
+	fs.Parse(args)
+
+	var p signing.Payload
+	if err := json.Unmarshal(readInput(*in), &p); err != nil {
+		log.Fatalf("invalid transaction: %v", err)
+	}
+	printJSON(signPayload(&p))
+}
+
+func txSubmit(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("tx submit", flag.ExitOnError)
+	in := fs.String("in", "", "signed transaction from tx sign, - or empty for standard input")
+	fs.Parse(args)
+
+	var st signing.SignedTransaction
+	if err := json.Unmarshal(readInput(*in), &st); err != nil {
+		log.Fatalf("invalid transaction: %v", err)
+	}
+	if err := st.Verify(); err != nil {
+		log.Fatal(err)
+	}
+	submit(ctx, &st)
+}
+
+func txSend(ctx context.Context, args []string) {
+	submit(ctx, signPayload(buildPayload(ctx, "tx send", args)))
+}
+
+func txGet(ctx context.Context, args []string) {
+	lookup, err := node.Transaction(ctx, oneArg(args, "a transaction ID"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	render(lookup, func(w *tabwriter.Writer) {
+		t := lookup.Transaction
+		fmt.Fprintf(w, "ID:\t%s\n", lookup.ID)
+		fmt.Fprintf(w, "Status:\t%s\n", lookup.Status)
+		if lookup.BlockHeight != nil {
+			fmt.Fprintf(w, "Block:\t%d %s\n", *lookup.BlockHeight, lookup.BlockHash)
+			fmt.Fprintf(w, "Confirmations:\t%d\n", lookup.Confirmations)
+		}
+		fmt.Fprintf(w, "From:\t%s\n", t.GetSender())
+		fmt.Fprintf(w, "To:\t%s\n", t.GetRecipient())
+		fmt.Fprintf(w, "Value:\t%s\n", t.GetValue())
+		fmt.Fprintf(w, "Fee:\t%s\n", t.GetFee())
+		fmt.Fprintf(w, "Nonce:\t%d\n", t.GetNonce())
+	})
+}
+
+// printLookups writes one row per transaction.
+func printLookups(w *tabwriter.Writer, lookups []*block.TransactionLookup) {
+	fmt.Fprintln(w, "ID\tSTATUS\tHEIGHT\tFROM\tTO\tVALUE\tFEE")
+	for _, l := range lookups {
+		height := "-"
+		if l.BlockHeight != nil {
+			height = fmt.Sprint(*l.BlockHeight)
+		}
+		t := l.Transaction
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			shortHash(l.ID), l.Status, height, t.GetSender(), t.GetRecipient(), t.GetValue(), t.GetFee())
+	}
+}
+
+// printTransactions writes one row per transaction of a block or the pool.
+func printTransactions(w *tabwriter.Writer, transactions []*block.Transaction) {
+	fmt.Fprintln(w, "ID\tFROM\tTO\tVALUE\tFEE\tNONCE")
+	for _, t := range transactions {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\n",
+			shortHash(t.ID()), t.GetSender(), t.GetRecipient(), t.GetValue(), t.GetFee(), t.GetNonce())
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"block/struct/address"
+	"block/struct/keystore"
+	"block/struct/utils"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+type walletOutput struct {
+	Address    string `json:"address"`
+	PublicKey  string `json:"publicKey,omitempty"`
+	PrivateKey string `json:"privateKey,omitempty"`
+}
+
+func walletCommand(ctx context.Context, args []string) {
+	if len(args) == 0 {
+		log.Fatal("usage: blockctl wallet create|list|import|export")
+	}
+	switch args[0] {
+	case "create":
+		walletCreate(ctx, args[1:])
+	case "list":
+		walletList()
+	case "import":
+		walletImport(args[1:])
+	case "export":
+		walletExport(args[1:])
+	default:
+		log.Fatalf("unknown wallet command %q", args[0])
+	}
+}
+
+func printWallet(wo walletOutput) {
+	render(wo, func(w *tabwriter.Writer) {
+		fmt.Fprintf(w, "Address:\t%s\n", wo.Address)
+		if wo.PublicKey != "" {
+			fmt.Fprintf(w, "Public key:\t%s\n", wo.PublicKey)
+		}
+		if wo.PrivateKey != "" {
+			fmt.Fprintf(w, "Private key:\t%s\n", wo.PrivateKey)
+		}
+	})
+}
+
+func walletCreate(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("wallet create", flag.ExitOnError)
+	register := fs.Bool("register", false, "record the new address on the node")
+	fs.Parse(args)
+
+	privateKey, err := ks.Create(readPassword())
+	if err != nil {
+		log.Fatal(err)
+	}
+	wo := walletOutput{
+		Address:   address.FromPublicKey(&privateKey.PublicKey),
+		PublicKey: utils.PublicKeyString(&privateKey.PublicKey),
+	}
+	if *register {
+		if _, err := node.RegisterWallet(ctx, wo.PublicKey); err != nil {
+			log.Fatalf("created %s but failed to register it: %v", wo.Address, err)
+		}
+	}
+	printWallet(wo)
+}
+
+func walletList() {
+	addresses, err := ks.List()
+	if err != nil {
+		log.Fatal(err)
+	}
+	render(addresses, func(w *tabwriter.Writer) {
+		fmt.Fprintln(w, "ADDRESS")
+		for _, a := range addresses {
+			fmt.Fprintln(w, a)
+		}
+	})
+}
+
+func walletImport(args []string) {
+	fs := flag.NewFlagSet("wallet import", flag.ExitOnError)
+	keyFile := fs.String("key-file", "", "file holding a hex private key")
+	file := fs.String("file", "", "keystore file to copy in")
+	fs.Parse(args)
+
+	switch {
+	case *keyFile != "":
+		data, err := os.ReadFile(*keyFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		privateKey, err := utils.PrivateKeyFromHex(strings.TrimSpace(string(data)))
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := ks.Import(privateKey, readPassword()); err != nil {
+			log.Fatal(err)
+		}
+		printWallet(walletOutput{
+			Address:   address.FromPublicKey(&privateKey.PublicKey),
+			PublicKey: utils.PublicKeyString(&privateKey.PublicKey),
+		})
+	case *file != "":
+		data, err := os.ReadFile(*file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		var kf keystore.KeyFile
+		if err := json.Unmarshal(data, &kf); err != nil {
+			log.Fatalf("%v: %v", keystore.ErrFormat, err)
+		}
+		// Only take in files we can open
+		if _, err := kf.Decrypt(readPassword()); err != nil {
+			log.Fatal(err)
+		}
+		if err := ks.Write(&kf); err != nil {
+			log.Fatal(err)
+		}
+		printWallet(walletOutput{Address: kf.Address, PublicKey: kf.PublicKey})
+	default:
+		log.Fatal("wallet import needs -key-file or -file")
+	}
+}
+
+func walletExport(args []string) {
+	fs := flag.NewFlagSet("wallet export", flag.ExitOnError)
+	private := fs.Bool("private", false, "include the decrypted private key")
+	keyFile := fs.Bool("key-file", false, "print the encrypted key file, for wallet import -file")
+	fs.Parse(args)
+	kf, err := ks.Get(oneArg(fs.Args(), "an address"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *keyFile {
+		data, err := json.MarshalIndent(kf, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	wo := walletOutput{Address: kf.Address, PublicKey: kf.PublicKey}
+	if *private {
+		privateKey, err := kf.Decrypt(readPassword())
+		if err != nil {
+			log.Fatal(err)
+		}
+		wo.PrivateKey = utils.PrivateKeyString(privateKey)
+	}
+	printWallet(wo)
+}
@@ -0,0 +1,90 @@
+// Command genesis writes a genesis file defining a new network.
+//
+//	go run ./cmd/genesis -chain-id mynet -alloc 1Abc...=1000 -out genesis.json
+package main
+
+import (
+	"block/struct/amount"
+	"block/struct/block"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// allocations collects repeated -alloc address=amount flags.
+type allocations []block.Allocation
+
+func (a *allocations) String() string {
+	parts := make([]string, len(*a))
+	for i, alloc := range *a {
+		parts[i] = fmt.Sprintf("%s=%s", alloc.Address, alloc.Amount)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (a *allocations) Set(s string) error {
+	address, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return fmt.Errorf("expected address=amount, got %q", s)
+	}
+	amt, err := amount.Parse(value)
+	if err != nil {
+		return fmt.Errorf("amount of %s: %v", address, err)
+	}
+	*a = append(*a, block.Allocation{Address: address, Amount: amt})
+	return nil
+}
+
+func init() {
+	log.SetPrefix("genesis: ")
+	log.SetFlags(0)
+}
+
+func main() {
+	genesis := block.DefaultGenesis()
+	params := genesis.Params
+
+	var allocs allocations
+	out := flag.String("out", block.GENESIS_FILE, "file to write")
+	force := flag.Bool("force", false, "overwrite an existing file")
+	flag.StringVar(&genesis.ChainID, "chain-id", genesis.ChainID, "chain ID of the network")
+	flag.Int64Var(&genesis.Timestamp, "timestamp", time.Now().Unix(), "genesis block timestamp in Unix seconds")
+	flag.IntVar(&params.InitialDifficulty, "difficulty", params.InitialDifficulty, "initial difficulty in leading zero bits")
+	flag.IntVar(&params.MinDifficulty, "min-difficulty", params.MinDifficulty, "lowest difficulty a retarget may reach")
+	flag.IntVar(&params.MaxDifficulty, "max-difficulty", params.MaxDifficulty, "highest difficulty a retarget may reach")
+	flag.Int64Var(&params.TargetBlockTimeSec, "block-time", params.TargetBlockTimeSec, "target seconds between blocks")
+	flag.Uint64Var(&params.RetargetInterval, "retarget-interval", params.RetargetInterval, "blocks between difficulty adjustments")
+	flag.Uint64Var(&params.HalvingInterval, "halving-interval", params.HalvingInterval, "blocks between subsidy halvings")
+	flag.Uint64Var(&params.CoinbaseMaturity, "coinbase-maturity", params.CoinbaseMaturity, "blocks before a mining reward can be spent")
+	flag.IntVar(&params.MaxBlockSize, "max-block-size", params.MaxBlockSize, "maximum bytes of transactions per block")
+	subsidy := flag.String("subsidy", params.InitialSubsidy.String(), "initial block subsidy")
+	maxSupply := flag.String("max-supply", params.MaxSupply.String(), "maximum amount ever minted")
+	flag.Var(&allocs, "alloc", "premine `address=amount`, may be repeated")
+	flag.Parse()
+
+	var err error
+	if params.InitialSubsidy, err = amount.Parse(*subsidy); err != nil {
+		log.Fatalf("invalid subsidy: %v", err)
+	}
+	if params.MaxSupply, err = amount.Parse(*maxSupply); err != nil {
+		log.Fatalf("invalid max supply: %v", err)
+	}
+	genesis.Allocations = append(genesis.Allocations, allocs...)
+
+	if err := genesis.Validate(); err != nil {
+		log.Fatalf("invalid genesis: %v", err)
+	}
+	if _, err := os.Stat(*out); err == nil && !*force {
+		log.Fatalf("%s already exists, use -force to overwrite it", *out)
+	}
+	if err := genesis.Save(*out); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Wrote %s\n", *out)
+	fmt.Printf("Chain ID: %s\n", genesis.ChainID)
+	fmt.Printf("Genesis hash: %s\n", genesis.Hash())
+}
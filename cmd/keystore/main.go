@@ -0,0 +1,265 @@
+// Command keystore manages encrypted key files, such as the node's miner key.
+//
+//	go run ./cmd/keystore create
+//	go run ./cmd/keystore list
+//	go run ./cmd/keystore import -key-file key.hex
+//	go run ./cmd/keystore import -file 1Abc....json
+//	go run ./cmd/keystore export [-private] 1Abc...
+//	go run ./cmd/keystore mnemonic [-account n]
+//	go run ./cmd/keystore restore [-account n] [-count n] [-mnemonic-file file]
+//	go run ./cmd/keystore derive
+//
+// The password is read from -password-file, then KEYSTORE_PASSWORD, then a
+// line of standard input. mnemonic and restore keep the seed of an HD wallet
+// in the keystore; derive adds its next receiving key.
+package main
+
+import (
+	"block/struct/address"
+	"block/struct/keystore"
+	"block/struct/utils"
+	"block/struct/wallet"
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+var (
+	passwordFile string
+	stdin        = bufio.NewReader(os.Stdin)
+)
+
+func init() {
+	log.SetPrefix("keystore: ")
+	log.SetFlags(0)
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: keystore [-dir dir] [-password-file file] create|list|import|export|mnemonic|restore|derive [args]\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+// readLine prompts on standard error and reads a line of standard input.
+func readLine(prompt string) string {
+	fmt.Fprint(os.Stderr, prompt)
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		log.Fatalf("failed to read %s: %v", strings.TrimSuffix(prompt, ": "), err)
+	}
+	return strings.TrimRight(line, "\r\n")
+}
+
+func readPassword() string {
+	if passwordFile != "" {
+		data, err := os.ReadFile(passwordFile)
+		if err != nil {
+			log.Fatalf("failed to read password file: %v", err)
+		}
+		return strings.TrimRight(string(data), "\r\n")
+	}
+	if password, ok := os.LookupEnv("KEYSTORE_PASSWORD"); ok {
+		return password
+	}
+	return readLine("Password: ")
+}
+
+func main() {
+	dir := flag.String("dir", keystore.DEFAULT_DIR, "keystore directory")
+	flag.StringVar(&passwordFile, "password-file", "", "file holding the password")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+	}
+
+	ks := keystore.NewKeystore(*dir)
+	args := flag.Args()[1:]
+	switch flag.Arg(0) {
+	case "create":
+		create(ks)
+	case "list":
+		list(ks)
+	case "import":
+		importKey(ks, args)
+	case "export":
+		export(ks, args)
+	case "mnemonic":
+		mnemonic(ks, args)
+	case "restore":
+		restore(ks, args)
+	case "derive":
+		derive(ks)
+	default:
+		usage()
+	}
+}
+
+func create(ks *keystore.Keystore) {
+	privateKey, err := ks.Create(readPassword())
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(address.FromPublicKey(&privateKey.PublicKey))
+}
+
+func list(ks *keystore.Keystore) {
+	addresses, err := ks.List()
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, a := range addresses {
+		fmt.Println(a)
+	}
+}
+
+func importKey(ks *keystore.Keystore, args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	keyFile := fs.String("key-file", "", "file holding a hex private key")
+	file := fs.String("file", "", "keystore file to copy in")
+	fs.Parse(args)
+
+	switch {
+	case *keyFile != "":
+		data, err := os.ReadFile(*keyFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		privateKey, err := utils.PrivateKeyFromHex(strings.TrimSpace(string(data)))
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := ks.Import(privateKey, readPassword()); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(address.FromPublicKey(&privateKey.PublicKey))
+	case *file != "":
+		data, err := os.ReadFile(*file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		var kf keystore.KeyFile
+		if err := json.Unmarshal(data, &kf); err != nil {
+			log.Fatalf("%v: %v", keystore.ErrFormat, err)
+		}
+		// Only take in files we can open
+		if _, err := kf.Decrypt(readPassword()); err != nil {
+			log.Fatal(err)
+		}
+		if err := ks.Write(&kf); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(kf.Address)
+	default:
+		log.Fatal("import needs -key-file or -file")
+	}
+}
+
+func export(ks *keystore.Keystore, args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	private := fs.Bool("private", false, "print the decrypted private key instead of the key file")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		log.Fatal("export needs an address")
+	}
+
+	kf, err := ks.Get(fs.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *private {
+		privateKey, err := kf.Decrypt(readPassword())
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(utils.PrivateKeyString(privateKey))
+		return
+	}
+	data, err := json.MarshalIndent(kf, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(data))
+}
+
+// passphraseFlag adds the optional BIP-39 passphrase flag.
+func passphraseFlag(fs *flag.FlagSet) func() string {
+	file := fs.String("passphrase-file", "", "file holding the BIP-39 passphrase")
+	return func() string {
+		if *file == "" {
+			return ""
+		}
+		data, err := os.ReadFile(*file)
+		if err != nil {
+			log.Fatalf("failed to read passphrase file: %v", err)
+		}
+		return strings.TrimRight(string(data), "\r\n")
+	}
+}
+
+func createHD(ks *keystore.Keystore, phrase string, passphrase string, account uint32, count int) {
+	seed, err := wallet.SeedFromMnemonic(phrase, passphrase)
+	if err != nil {
+		log.Fatal(err)
+	}
+	password := readPassword()
+	hf, err := ks.CreateHD(seed, account, password)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Fprintf(os.Stderr, "Extended public key: %s\n", hf.ExtendedPublicKey)
+	for i := 0; i < count; i++ {
+		derived, err := ks.DeriveNext(password)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%d %s\n", derived.Index, derived.Address)
+	}
+}
+
+func mnemonic(ks *keystore.Keystore, args []string) {
+	fs := flag.NewFlagSet("mnemonic", flag.ExitOnError)
+	account := fs.Uint("account", 0, "HD account")
+	passphrase := passphraseFlag(fs)
+	fs.Parse(args)
+
+	phrase, err := wallet.NewMnemonic()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Fprintf(os.Stderr, "Write down this mnemonic, it restores every address of the wallet:\n\n%s\n\n", phrase)
+	createHD(ks, phrase, passphrase(), uint32(*account), 1)
+}
+
+func restore(ks *keystore.Keystore, args []string) {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	account := fs.Uint("account", 0, "HD account")
+	count := fs.Int("count", 1, "receiving keys to restore")
+	mnemonicFile := fs.String("mnemonic-file", "", "file holding the mnemonic")
+	passphrase := passphraseFlag(fs)
+	fs.Parse(args)
+
+	var phrase string
+	if *mnemonicFile != "" {
+		data, err := os.ReadFile(*mnemonicFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		phrase = strings.TrimSpace(string(data))
+	} else {
+		phrase = readLine("Mnemonic: ")
+	}
+	createHD(ks, strings.Join(strings.Fields(phrase), " "), passphrase(), uint32(*account), *count)
+}
+
+func derive(ks *keystore.Keystore) {
+	derived, err := ks.DeriveNext(readPassword())
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%d %s\n", derived.Index, derived.Address)
+}
@@ -0,0 +1,161 @@
+// Command signtx builds and signs a transaction on this machine and prints it
+// as JSON for PUT /transactions. The key comes from the keystore; only the
+// public key and signature leave the machine.
+//
+//	go run ./cmd/signtx -from 1Abc... -to 1Def... -value 5 -chain-id mynet -nonce 3
+//	go run ./cmd/signtx -from 1Abc... -to 1Def... -value 5 -node http://localhost:5001 -submit
+//
+// Without -node the tool never touches the network, so -chain-id and -nonce
+// are required. With -node they default to the node's chain and the sender's
+// next nonce. The keystore password is read from -password-file, then
+// KEYSTORE_PASSWORD, then a line of standard input.
+package main
+
+import (
+	"block/struct/amount"
+	"block/struct/block"
+	"block/struct/keystore"
+	"block/struct/signing"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// NODE_TIMEOUT_SEC bounds each request to the node.
+const NODE_TIMEOUT_SEC = 10
+
+func init() {
+	log.SetPrefix("signtx: ")
+	log.SetFlags(0)
+}
+
+func readPassword(passwordFile string) string {
+	if passwordFile != "" {
+		data, err := os.ReadFile(passwordFile)
+		if err != nil {
+			log.Fatalf("failed to read password file: %v", err)
+		}
+		return strings.TrimRight(string(data), "\r\n")
+	}
+	if password, ok := os.LookupEnv("KEYSTORE_PASSWORD"); ok {
+		return password
+	}
+	fmt.Fprint(os.Stderr, "Password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		log.Fatalf("failed to read password: %v", err)
+	}
+	return strings.TrimRight(line, "\r\n")
+}
+
+// getJSON decodes the response of a GET to the node.
+func getJSON(client *http.Client, url string, v interface{}) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s: %s", url, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func main() {
+	dir := flag.String("keystore", keystore.DEFAULT_DIR, "keystore directory")
+	passwordFile := flag.String("password-file", "", "file holding the keystore password")
+	from := flag.String("from", "", "sender address, a key in the keystore")
+	to := flag.String("to", "", "recipient address")
+	value := flag.String("value", "", "amount to send")
+	fee := flag.String("fee", "0", "fee for the miner")
+	message := flag.String("message", "", "message stored with the transaction")
+	chainID := flag.String("chain-id", "", "chain to sign for")
+	nonce := flag.Int64("nonce", -1, "sender nonce")
+	node := flag.String("node", "", "node URL used to fill in the chain ID and nonce")
+	submit := flag.Bool("submit", false, "send the signed transaction to -node")
+	flag.Parse()
+
+	p := &signing.Payload{ChainID: *chainID, Sender: *from, Recipient: *to, Message: *message}
+	var err error
+	if p.Value, err = amount.Parse(*value); err != nil {
+		log.Fatalf("invalid value: %v", err)
+	}
+	if p.Fee, err = amount.Parse(*fee); err != nil {
+		log.Fatalf("invalid fee: %v", err)
+	}
+
+	client := &http.Client{Timeout: NODE_TIMEOUT_SEC * time.Second}
+	nodeURL := strings.TrimRight(*node, "/")
+	if p.ChainID == "" {
+		if nodeURL == "" {
+			log.Fatal("-chain-id is required without -node")
+		}
+		var g block.GenesisResponse
+		if err := getJSON(client, nodeURL+"/genesis", &g); err != nil {
+			log.Fatalf("failed to fetch chain ID: %v", err)
+		}
+		p.ChainID = g.ChainID
+	}
+	if *nonce < 0 {
+		if nodeURL == "" {
+			log.Fatal("-nonce is required without -node")
+		}
+		var n struct {
+			Nonce uint64 `json:"nonce"`
+		}
+		if err := getJSON(client, fmt.Sprintf("%s/accounts/%s/nonce", nodeURL, p.Sender), &n); err != nil {
+			log.Fatalf("failed to fetch nonce: %v", err)
+		}
+		p.Nonce = n.Nonce
+	} else {
+		p.Nonce = uint64(*nonce)
+	}
+	if err := p.Check(); err != nil {
+		log.Fatal(err)
+	}
+
+	privateKey, err := keystore.NewKeystore(*dir).Unlock(p.Sender, readPassword(*passwordFile))
+	if err != nil {
+		log.Fatal(err)
+	}
+	st, err := signing.SignTransaction(privateKey, p)
+	if err != nil {
+		log.Fatal(err)
+	}
+	body, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if !*submit {
+		fmt.Println(string(body))
+		return
+	}
+	if nodeURL == "" {
+		log.Fatal("-submit needs -node")
+	}
+	req, err := http.NewRequest(http.MethodPut, nodeURL+"/transactions", bytes.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+	reply, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		log.Fatalf("node rejected the transaction: %s: %s", resp.Status, strings.TrimSpace(string(reply)))
+	}
+	fmt.Println(strings.TrimSpace(string(reply)))
+}
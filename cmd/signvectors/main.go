@@ -0,0 +1,254 @@
+// Command signvectors writes and checks the published test vectors of the
+// transaction signing encoding.
+//
+//	go run ./cmd/signvectors          # check struct/signing/testvectors.json
+//	go run ./cmd/signvectors -write   # regenerate it
+//
+// Encodings and digests are deterministic. ECDSA signatures are not, so the
+// published signatures are one valid signature each: clients should check
+// that they verify rather than reproduce them.
+package main
+
+import (
+	"block/struct/address"
+	"block/struct/amount"
+	"block/struct/signing"
+	"block/struct/utils"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+	"os"
+	"strconv"
+)
+
+const VECTORS_FILE = "struct/signing/testvectors.json"
+
+// File is the layout of the published vectors. Amounts are base units and,
+// like nonces, are decimal strings so that 64-bit values survive JSON
+// parsers that use doubles.
+type File struct {
+	Version     int      `json:"version"`
+	Description string   `json:"description"`
+	Vectors     []Vector `json:"vectors"`
+}
+
+type Vector struct {
+	Name       string `json:"name"`
+	ChainID    string `json:"chainId"`
+	Sender     string `json:"sender"`
+	Recipient  string `json:"recipient"`
+	Value      string `json:"value"`
+	Fee        string `json:"fee"`
+	Nonce      string `json:"nonce"`
+	Message    string `json:"message"`
+	Encoding   string `json:"encoding"`
+	Digest     string `json:"digest"`
+	PrivateKey string `json:"privateKey"`
+	PublicKey  string `json:"publicKey"`
+	Signature  string `json:"signature"`
+}
+
+// testKey derives a fixed P-256 key from a label. It is for test vectors
+// only and must never hold funds.
+func testKey(label string) *ecdsa.PrivateKey {
+	curve := elliptic.P256()
+	seed := sha256.Sum256([]byte(label))
+	n := new(big.Int).Sub(curve.Params().N, big.NewInt(1))
+	d := new(big.Int).Mod(new(big.Int).SetBytes(seed[:]), n)
+	d.Add(d, big.NewInt(1))
+	x, y := curve.ScalarBaseMult(d.FillBytes(make([]byte, 32)))
+	return &ecdsa.PrivateKey{PublicKey: ecdsa.PublicKey{Curve: curve, X: x, Y: y}, D: d}
+}
+
+func payloads() []struct {
+	name    string
+	payload *signing.Payload
+} {
+	return []struct {
+		name    string
+		payload *signing.Payload
+	}{
+		{"simple transfer", &signing.Payload{
+			ChainID:   "go-blockchain-devnet",
+			Recipient: "1BoatSLRHtKNngkdXEeobR76b53LETtpyT",
+			Value:     100000000,
+			Fee:       1000,
+			Nonce:     0,
+			Message:   "rent",
+		}},
+		{"empty message and zero fee", &signing.Payload{
+			ChainID:   "go-blockchain-devnet",
+			Recipient: "1BoatSLRHtKNngkdXEeobR76b53LETtpyT",
+			Value:     1,
+			Fee:       0,
+			Nonce:     7,
+			Message:   "",
+		}},
+		{"unicode message", &signing.Payload{
+			ChainID:   "testnet-2",
+			Recipient: "1HUsHEHLWHeGmQgW4dzi8vAf5ebcUKGHrp",
+			Value:     250000000,
+			Fee:       12345,
+			Nonce:     42,
+			Message:   "café ☕ 支払い",
+		}},
+		{"maximum integers", &signing.Payload{
+			ChainID:   "x",
+			Recipient: "b",
+			Value:     amount.Amount(math.MaxUint64),
+			Fee:       amount.Amount(math.MaxUint64),
+			Nonce:     math.MaxUint64,
+			Message:   "max",
+		}},
+	}
+}
+
+func toVector(name string, p *signing.Payload, key *ecdsa.PrivateKey) (Vector, error) {
+	signature, err := signing.Sign(key, p)
+	if err != nil {
+		return Vector{}, err
+	}
+	digest := p.Digest()
+	return Vector{
+		Name:       name,
+		ChainID:    p.ChainID,
+		Sender:     p.Sender,
+		Recipient:  p.Recipient,
+		Value:      strconv.FormatUint(uint64(p.Value), 10),
+		Fee:        strconv.FormatUint(uint64(p.Fee), 10),
+		Nonce:      strconv.FormatUint(p.Nonce, 10),
+		Message:    p.Message,
+		Encoding:   hex.EncodeToString(p.Encode()),
+		Digest:     hex.EncodeToString(digest[:]),
+		PrivateKey: utils.PrivateKeyString(key),
+		PublicKey:  utils.PublicKeyString(&key.PublicKey),
+		Signature:  signature.String(),
+	}, nil
+}
+
+func (v *Vector) payload() (*signing.Payload, error) {
+	value, err := strconv.ParseUint(v.Value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("value: %v", err)
+	}
+	fee, err := strconv.ParseUint(v.Fee, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("fee: %v", err)
+	}
+	nonce, err := strconv.ParseUint(v.Nonce, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("nonce: %v", err)
+	}
+	return &signing.Payload{
+		ChainID:   v.ChainID,
+		Sender:    v.Sender,
+		Recipient: v.Recipient,
+		Value:     amount.Amount(value),
+		Fee:       amount.Amount(fee),
+		Nonce:     nonce,
+		Message:   v.Message,
+	}, nil
+}
+
+// check recomputes a published vector and verifies its signature.
+func (v *Vector) check() error {
+	p, err := v.payload()
+	if err != nil {
+		return err
+	}
+	encoding := p.Encode()
+	if hex.EncodeToString(encoding) != v.Encoding {
+		return fmt.Errorf("encoding is %x", encoding)
+	}
+	if digest := p.Digest(); hex.EncodeToString(digest[:]) != v.Digest {
+		return fmt.Errorf("digest is %x", digest)
+	}
+	decoded, err := signing.Decode(encoding)
+	if err != nil {
+		return fmt.Errorf("decode: %v", err)
+	}
+	if !bytes.Equal(decoded.Encode(), encoding) {
+		return fmt.Errorf("decoding does not round-trip")
+	}
+	publicKey, err := utils.PublicKeyFromString(v.PublicKey)
+	if err != nil {
+		return err
+	}
+	signature, err := utils.SignatureFromString(v.Signature)
+	if err != nil {
+		return err
+	}
+	if !signing.Verify(publicKey, p, signature) {
+		return fmt.Errorf("signature does not verify")
+	}
+	return nil
+}
+
+func init() {
+	log.SetPrefix("signvectors: ")
+	log.SetFlags(0)
+}
+
+func main() {
+	write := flag.Bool("write", false, "regenerate the vectors instead of checking them")
+	path := flag.String("file", VECTORS_FILE, "vectors file")
+	flag.Parse()
+
+	if *write {
+		f := File{
+			Version:     int(signing.VERSION),
+			Description: "Transaction signing encoding test vectors, see struct/signing/SIGNING.md",
+		}
+		for i, p := range payloads() {
+			// Senders are the addresses of the signing keys, as the chain requires
+			key := testKey(fmt.Sprintf("signing test vector %d", i))
+			p.payload.Sender = address.FromPublicKey(&key.PublicKey)
+			v, err := toVector(p.name, p.payload, key)
+			if err != nil {
+				log.Fatal(err)
+			}
+			f.Vectors = append(f.Vectors, v)
+		}
+		data, err := json.MarshalIndent(f, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(*path, append(data, '\n'), 0644); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Wrote %d vectors to %s\n", len(f.Vectors), *path)
+		return
+	}
+
+	data, err := os.ReadFile(*path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		log.Fatalf("failed to parse %s: %v", *path, err)
+	}
+	if f.Version != int(signing.VERSION) {
+		log.Fatalf("vectors are for version %d, the encoding is version %d", f.Version, signing.VERSION)
+	}
+	failed := 0
+	for _, v := range f.Vectors {
+		if err := v.check(); err != nil {
+			fmt.Printf("FAIL %s: %v\n", v.Name, err)
+			failed++
+			continue
+		}
+		fmt.Printf("ok   %s\n", v.Name)
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -6,5 +6,6 @@ require (
 	github.com/btcsuite/btcutil v1.0.2
 	github.com/gorilla/mux v1.8.1
 	github.com/rs/cors v1.11.1
+	github.com/tyler-smith/go-bip39 v1.1.0
 	golang.org/x/crypto v0.36.0
 )
@@ -4,60 +4,55 @@ import (
 	"block/middleware"
 	"block/server/handlers"
 	"block/struct/block"
+	"block/struct/config"
 	"block/struct/utils"
 	"block/struct/wallet"
+	"errors"
+	"flag"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"strconv"
-
-	"github.com/gorilla/mux"
 )
 
 var cache map[string]*block.Blockchain = make(map[string]*block.Blockchain)
 
 type BlockchainServer struct {
-	port   uint16
-	Wallet *wallet.Wallet
+	config  *config.Config
+	genesis *block.Genesis
+	Wallet  *wallet.Wallet
 }
 
 func (bcs *BlockchainServer) Port() uint16 {
-	return bcs.port
+	return bcs.config.Port
+}
+
+// Config returns the configuration the node was started with.
+func (bcs *BlockchainServer) Config() *config.Config {
+	return bcs.config
 }
 
 func (bcs *BlockchainServer) GetWallet() *wallet.Wallet {
 	return bcs.Wallet
 }
 
-func NewBlockchainServer(port uint16) *BlockchainServer {
+func NewBlockchainServer(cfg *config.Config, genesis *block.Genesis, minerWallet *wallet.Wallet) *BlockchainServer {
 	return &BlockchainServer{
-		port:   port,
-		Wallet: nil,
+		config:  cfg,
+		genesis: genesis,
+		Wallet:  minerWallet,
 	}
 }
 
 func (bcs *BlockchainServer) GetBlockchain() *block.Blockchain {
 	bc, ok := cache["blockchain"]
 	if !ok {
-		// Try to load existing blockchain first
-		bc = block.LoadBlockchain(bcs.Port())
-		if bc == nil {
-			// If no existing blockchain found, create a new one
-			minersWallet := wallet.NewWallet()
-			bc = block.NewBlockchain(minersWallet.BlockchainAddress(), bcs.Port())
-			bcs.Wallet = minersWallet
-
-			log.Printf("Created new blockchain")
-			log.Printf("privateKey %v", minersWallet.PrivateKeyStr())
-			log.Printf("publicKey %v", minersWallet.PublicKeyStr())
-			log.Printf("blockchainAddress %v", minersWallet.BlockchainAddress())
-
-			// Save the new blockchain
-			if err := bc.SaveBlockchain(); err != nil {
-				log.Printf("Error saving new blockchain: %v", err)
-			}
-		} else {
-			log.Printf("Loaded existing blockchain")
+		// Open the stored blockchain, or start one from the genesis block
+		var err error
+		bc, err = block.OpenBlockchain(bcs.Wallet.BlockchainAddress(), bcs.config, bcs.genesis)
+		if err != nil {
+			log.Fatalf("ERROR: %v", err)
 		}
 		cache["blockchain"] = bc
 	}
@@ -82,27 +77,12 @@ func enableCORS(next http.Handler) http.Handler {
 func (bcs *BlockchainServer) Run() {
 	bcs.GetBlockchain().Run()
 
-	router := mux.NewRouter()
+	router := handlers.NewRouter(bcs)
 	router.Use(utils.CorsMiddleware())
-
-	handler := handlers.NewBlockchainServerHandler(bcs)
-
-	router.HandleFunc("/chain", handler.GetChain)
-	router.HandleFunc("/balance", handler.Balance)
-	router.HandleFunc("/consensus", handler.Consensus)
-	router.HandleFunc("/mine", handler.HandleMine)
-	router.HandleFunc("/mine/start", handler.StartMine)
-	router.HandleFunc("/miner/blocks", handler.GetBlocks)
-	router.HandleFunc("/miner/wallet", handler.MinerWallet)
-	router.HandleFunc("/transactions", handler.Transactions)
-	router.HandleFunc("/wallet/register", handler.RegisterWallet)
-	router.HandleFunc("/wallets", handler.GetWallets)
-	router.HandleFunc("/nodes", handler.GetNodes)
-	router.HandleFunc("/reset", handler.Reset)
-	router.HandleFunc("/sign", handler.HandleSign).Methods("POST")
 	corsAndLoggingHandler := middleware.LoggingMiddleware(enableCORS(router))
 
-	log.Fatal(http.ListenAndServe("0.0.0.0:"+strconv.Itoa(int(bcs.Port())), corsAndLoggingHandler))
+	listen := net.JoinHostPort(bcs.config.ListenHost, strconv.Itoa(int(bcs.Port())))
+	log.Fatal(http.ListenAndServe(listen, corsAndLoggingHandler))
 }
 
 func init() {
@@ -110,14 +90,46 @@ func init() {
 }
 
 func main() {
-	portStr := os.Getenv("PORT")
-	port, err := strconv.Atoi(portStr)
-	if err != nil || port <= 0 {
-		port = 5001
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
 	}
+	if err != nil {
+		log.Fatalf("ERROR: %v", err)
+	}
+
+	log.Printf("Port: %d\n", cfg.Port)
 
-	log.Printf("Port: %d\n", port)
+	genesis, err := block.LoadGenesis(cfg.GenesisFile)
+	if os.IsNotExist(err) {
+		log.Printf("No genesis file at %s, using the default network", cfg.GenesisFile)
+		genesis = block.DefaultGenesis()
+	} else if err != nil {
+		log.Fatalf("ERROR: %v", err)
+	}
+	log.Printf("Chain ID: %s, genesis: %s\n", genesis.ChainID, genesis.Hash())
+
+	// Open the chain before the miner key: the data-directory lock then also
+	// keeps other nodes off the default keystore inside it
+	bc, err := block.OpenBlockchain("", cfg, genesis)
+	if err != nil {
+		log.Fatalf("ERROR: %v", err)
+	}
+	cache["blockchain"] = bc
+
+	minerWallet, err := loadMinerWallet(cfg)
+	if err != nil {
+		log.Fatalf("ERROR: %v", err)
+	}
+	bc.SetMinerAddress(minerWallet.BlockchainAddress())
+	log.Printf("Miner address: %s\n", minerWallet.BlockchainAddress())
+
+	// Key endpoints take or return private keys: /sign, the mnemonic
+	// endpoints and server-side key generation
+	if cfg.DisableKeyEndpoints {
+		log.Printf("Key endpoints are disabled")
+	}
 
-	app := NewBlockchainServer(uint16(port))
+	app := NewBlockchainServer(cfg, genesis, minerWallet)
 	app.Run()
 }
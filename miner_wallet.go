@@ -0,0 +1,69 @@
+package main
+
+import (
+	"block/struct/config"
+	"block/struct/keystore"
+	"block/struct/wallet"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// minerPassword reads the keystore password from the configured password file
+// or MINER_PASSWORD. Without either the key is sealed with an empty password.
+func minerPassword(file string) (string, error) {
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read password file: %v", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	password, ok := os.LookupEnv("MINER_PASSWORD")
+	if !ok {
+		log.Printf("WARNING: MINER_PASSWORD is not set, the miner key is protected by an empty password")
+	}
+	return password, nil
+}
+
+// loadMinerWallet unlocks the miner key from the configured keystore. The
+// configured miner address picks the key when the keystore holds several; an
+// empty keystore gets a new key.
+func loadMinerWallet(cfg *config.Config) (*wallet.Wallet, error) {
+	dir := cfg.KeystoreDir
+	ks := keystore.NewKeystore(dir)
+
+	password, err := minerPassword(cfg.MinerPasswordFile)
+	if err != nil {
+		return nil, err
+	}
+
+	minerAddress := cfg.MinerAddress
+	if minerAddress == "" {
+		addresses, err := ks.List()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list keystore %s: %v", dir, err)
+		}
+		switch len(addresses) {
+		case 0:
+			privateKey, err := ks.Create(password)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create miner key: %v", err)
+			}
+			w := wallet.NewWalletFromPrivateKey(privateKey)
+			log.Printf("Created miner key %s in keystore %s", w.BlockchainAddress(), dir)
+			return w, nil
+		case 1:
+			minerAddress = addresses[0]
+		default:
+			return nil, fmt.Errorf("keystore %s holds %d keys, set MINER_ADDRESS or -miner-address to choose one", dir, len(addresses))
+		}
+	}
+
+	privateKey, err := ks.Unlock(minerAddress, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlock miner key %s: %w", minerAddress, err)
+	}
+	return wallet.NewWalletFromPrivateKey(privateKey), nil
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"block/struct/block"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type HistoryResponse struct {
+	Address      string                     `json:"address"`
+	Transactions []*block.TransactionLookup `json:"transactions"`
+}
+
+func (h *BlockchainServerHandler) AccountHistory(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		address := mux.Vars(req)["address"]
+		if !validateAddress(w, "address", address) {
+			return
+		}
+
+		response := HistoryResponse{
+			Address:      address,
+			Transactions: h.server.GetBlockchain().History(address),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Printf("ERROR: Failed to encode response: %v", err)
+		}
+	default:
+		log.Printf("ERROR: Invalid HTTP Method: %s", req.Method)
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type NonceResponse struct {
+	Address string `json:"address"`
+	Nonce   uint64 `json:"nonce"`
+}
+
+func (h *BlockchainServerHandler) AccountNonce(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		address := mux.Vars(req)["address"]
+		if !validateAddress(w, "address", address) {
+			return
+		}
+
+		response := NonceResponse{
+			Address: address,
+			Nonce:   h.server.GetBlockchain().NextNonce(address),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Printf("ERROR: Failed to encode response: %v", err)
+		}
+	default:
+		log.Printf("ERROR: Invalid HTTP Method: %s", req.Method)
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
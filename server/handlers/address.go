@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"block/struct/address"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// AddressError is the body returned when an address in a request is
+// malformed. Reason is one of "empty", "encoding", "length", "version" or
+// "checksum".
+type AddressError struct {
+	Error  string `json:"error"`
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+func addressErrorReason(err error) string {
+	switch {
+	case errors.Is(err, address.ErrEmpty):
+		return "empty"
+	case errors.Is(err, address.ErrEncoding):
+		return "encoding"
+	case errors.Is(err, address.ErrLength):
+		return "length"
+	case errors.Is(err, address.ErrVersion):
+		return "version"
+	case errors.Is(err, address.ErrChecksum):
+		return "checksum"
+	}
+	return "invalid"
+}
+
+// validateAddress checks the address in a request field. If it is malformed
+// it writes a 400 response and returns false.
+func validateAddress(w http.ResponseWriter, field string, value string) bool {
+	err := address.Validate(value)
+	if err == nil {
+		return true
+	}
+	log.Printf("ERROR: %s: %v", field, err)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(AddressError{
+		Error:  err.Error(),
+		Field:  field,
+		Reason: addressErrorReason(err),
+	})
+	return false
+}
@@ -12,16 +12,15 @@ func (h *BlockchainServerHandler) Balance(w http.ResponseWriter, req *http.Reque
 	switch req.Method {
 	case http.MethodGet:
 
-		br := &block.BalanceResponse{} // Use the BalanceResponse type
-
 		blockchainAddress := req.URL.Query().Get("blockchainAddress")
+		if !validateAddress(w, "blockchainAddress", blockchainAddress) {
+			return
+		}
 
-		balance, err := h.server.GetBlockchain().CalculateTotalBalance(blockchainAddress)
-
-		br.Balance = balance
+		br, err := h.server.GetBlockchain().CalculateTotalBalance(blockchainAddress)
 		if err != nil {
 			log.Printf("ERROR: %v", err)
-			br.Error = err.Error()
+			br = &block.BalanceResponse{Error: err.Error()}
 		}
 
 		m, _ := json.Marshal(br)
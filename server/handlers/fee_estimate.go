@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+func (h *BlockchainServerHandler) FeeEstimate(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		estimate := h.server.GetBlockchain().EstimateFee()
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(estimate); err != nil {
+			log.Printf("ERROR: Failed to encode response: %v", err)
+		}
+	default:
+		log.Printf("ERROR: Invalid HTTP Method: %s", req.Method)
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
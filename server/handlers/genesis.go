@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"block/struct/block"
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+func (h *BlockchainServerHandler) Genesis(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		bc := h.server.GetBlockchain()
+		response := &block.GenesisResponse{
+			ChainID: bc.Genesis().ChainID,
+			Hash:    bc.GenesisHash(),
+			Genesis: bc.Genesis(),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Printf("ERROR: Failed to encode response: %v", err)
+		}
+	default:
+		log.Printf("ERROR: Invalid HTTP Method: %s", req.Method)
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"block/struct/block"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// GetBlock returns one block, looked up by height when the ID is a number and
+// by hash otherwise.
+func (h *BlockchainServerHandler) GetBlock(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		id := mux.Vars(req)["id"]
+		bc := h.server.GetBlockchain()
+
+		var b *block.Block
+		if height, err := strconv.ParseUint(id, 10, 64); err == nil {
+			b = bc.GetBlockByHeight(height)
+		} else {
+			b = bc.GetBlockByHash(id)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if b == nil {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"message": fmt.Sprintf("Block %s not found", id)})
+			return
+		}
+		if err := json.NewEncoder(w).Encode(b); err != nil {
+			log.Printf("ERROR: Failed to encode response: %v", err)
+		}
+	default:
+		log.Printf("ERROR: Invalid HTTP Method: %s", req.Method)
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
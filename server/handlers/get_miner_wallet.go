@@ -1,18 +1,27 @@
 package handlers
 
 import (
-	"io"
+	"encoding/json"
 	"log"
 	"net/http"
 )
 
+type MinerWalletResponse struct {
+	PublicKey         string `json:"publicKey"`
+	BlockchainAddress string `json:"blockchainAddress"`
+}
+
+// MinerWallet returns the address and public key the node mines to. The
+// private key stays in the keystore.
 func (h *BlockchainServerHandler) MinerWallet(w http.ResponseWriter, req *http.Request) {
 	switch req.Method {
-	case http.MethodPost:
-		w.Header().Add("Content-Type", "application/json")
+	case http.MethodGet, http.MethodPost:
 		myWallet := h.server.GetWallet()
-		m, _ := myWallet.MarshalJSON()
-		io.WriteString(w, string(m[:]))
+		w.Header().Add("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(MinerWalletResponse{
+			PublicKey:         myWallet.PublicKeyStr(),
+			BlockchainAddress: myWallet.BlockchainAddress(),
+		})
 	default:
 		w.WriteHeader(http.StatusBadRequest)
 		log.Println("ERROR: Invalid HTTP Method")
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"block/struct/block"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *BlockchainServerHandler) GetTransaction(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		lookup := h.server.GetBlockchain().FindTransaction(mux.Vars(req)["id"])
+
+		w.Header().Set("Content-Type", "application/json")
+		if lookup.Status == block.TX_STATUS_UNKNOWN {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		if err := json.NewEncoder(w).Encode(lookup); err != nil {
+			log.Printf("ERROR: Failed to encode response: %v", err)
+		}
+	default:
+		log.Printf("ERROR: Invalid HTTP Method: %s", req.Method)
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
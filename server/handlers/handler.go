@@ -2,8 +2,12 @@ package handlers
 
 import (
 	"block/struct/block"
+	"block/struct/config"
 	"block/struct/wallet"
+	"encoding/json"
 	"fmt"
+	"log"
+	"net/http"
 	"reflect"
 )
 
@@ -20,6 +24,7 @@ type BlockchainServer interface {
 	Port() uint16
 	GetWallet() *wallet.Wallet
 	GetBlockchain() *block.Blockchain
+	Config() *config.Config
 }
 
 type BlockchainServerHandler struct {
@@ -29,3 +34,18 @@ type BlockchainServerHandler struct {
 func NewBlockchainServerHandler(s BlockchainServer) *BlockchainServerHandler {
 	return &BlockchainServerHandler{server: s}
 }
+
+// requireKeyEndpoints guards endpoints that take or return private keys or
+// mnemonics. Nodes that disable them answer 403.
+func (h *BlockchainServerHandler) requireKeyEndpoints(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if h.server.Config().DisableKeyEndpoints {
+			log.Printf("ERROR: Refusing %s %s, key endpoints are disabled", req.Method, req.URL.Path)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]string{"message": "This node does not handle private keys, sign locally and send the public key and signature"})
+			return
+		}
+		next(w, req)
+	}
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"block/struct/wallet"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+type HDWalletRequest struct {
+	Mnemonic   string `json:"mnemonic"`
+	Passphrase string `json:"passphrase"`
+	Account    uint32 `json:"account"`
+}
+
+type HDWalletResponse struct {
+	Mnemonic          string             `json:"mnemonic,omitempty"`
+	Account           uint32             `json:"account"`
+	ExtendedPublicKey string             `json:"extendedPublicKey"`
+	Used              []wallet.HDAddress `json:"used"`
+	Next              wallet.HDAddress   `json:"next"`
+}
+
+// CreateMnemonic generates a mnemonic and returns it with the first receiving
+// address of the requested account. The node keeps nothing.
+func (h *BlockchainServerHandler) CreateMnemonic(w http.ResponseWriter, req *http.Request) {
+	h.requireKeyEndpoints(h.createMnemonic)(w, req)
+}
+
+func (h *BlockchainServerHandler) createMnemonic(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodPost:
+		var hdReq HDWalletRequest
+		if req.ContentLength != 0 {
+			if err := json.NewDecoder(req.Body).Decode(&hdReq); err != nil {
+				log.Printf("ERROR: Failed to decode request body: %v", err)
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"message": fmt.Sprintf("Failed to decode request body: %v", err)})
+				return
+			}
+		}
+
+		mnemonic, err := wallet.NewMnemonic()
+		if err != nil {
+			log.Printf("ERROR: Failed to generate mnemonic: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		hw, err := wallet.NewHDWalletFromMnemonic(mnemonic, hdReq.Passphrase, hdReq.Account)
+		if err != nil {
+			log.Printf("ERROR: Failed to derive HD wallet: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		first, err := hw.NextUnused(0, func(string) bool { return false })
+		if err != nil {
+			log.Printf("ERROR: Failed to derive address: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(HDWalletResponse{
+			Mnemonic:          mnemonic,
+			Account:           hdReq.Account,
+			ExtendedPublicKey: hw.ExtendedPublicKey(),
+			Used:              []wallet.HDAddress{},
+			Next:              first,
+		})
+	default:
+		log.Printf("ERROR: Invalid HTTP Method: %s", req.Method)
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+// RestoreMnemonic rederives an account from its mnemonic and reports the
+// addresses the chain has seen, scanning until wallet.HD_GAP_LIMIT unused
+// addresses in a row.
+func (h *BlockchainServerHandler) RestoreMnemonic(w http.ResponseWriter, req *http.Request) {
+	h.requireKeyEndpoints(h.restoreMnemonic)(w, req)
+}
+
+func (h *BlockchainServerHandler) restoreMnemonic(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodPost:
+		var hdReq HDWalletRequest
+		if err := json.NewDecoder(req.Body).Decode(&hdReq); err != nil {
+			log.Printf("ERROR: Failed to decode request body: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"message": fmt.Sprintf("Failed to decode request body: %v", err)})
+			return
+		}
+
+		hw, err := wallet.NewHDWalletFromMnemonic(hdReq.Mnemonic, hdReq.Passphrase, hdReq.Account)
+		if err != nil {
+			log.Printf("ERROR: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"message": err.Error()})
+			return
+		}
+
+		used, next, err := hw.Scan(wallet.HD_GAP_LIMIT, h.server.GetBlockchain().AddressUsed)
+		if err != nil {
+			log.Printf("ERROR: Failed to scan HD wallet: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if used == nil {
+			used = []wallet.HDAddress{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(HDWalletResponse{
+			Account:           hdReq.Account,
+			ExtendedPublicKey: hw.ExtendedPublicKey(),
+			Used:              used,
+			Next:              next,
+		})
+	default:
+		log.Printf("ERROR: Invalid HTTP Method: %s", req.Method)
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+// NextAddress derives the next unused receiving address from an extended
+// public key, starting at the optional from index.
+func (h *BlockchainServerHandler) NextAddress(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		hw, err := wallet.NewWatchOnlyHDWallet(req.URL.Query().Get("extendedPublicKey"))
+		if err != nil {
+			log.Printf("ERROR: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"message": err.Error()})
+			return
+		}
+		var from uint64
+		if s := req.URL.Query().Get("from"); s != "" {
+			if from, err = strconv.ParseUint(s, 10, 31); err != nil {
+				log.Printf("ERROR: Invalid from index: %v", err)
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"message": fmt.Sprintf("Invalid from index: %v", err)})
+				return
+			}
+		}
+
+		next, err := hw.NextUnused(uint32(from), h.server.GetBlockchain().AddressUsed)
+		if err != nil {
+			log.Printf("ERROR: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(next)
+	default:
+		log.Printf("ERROR: Invalid HTTP Method: %s", req.Method)
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"block/struct/block"
+	"block/struct/mempool"
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+type MempoolResponse struct {
+	mempool.Stats
+	Transactions []*block.Transaction `json:"transactions"`
+}
+
+func (h *BlockchainServerHandler) Mempool(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		bc := h.server.GetBlockchain()
+		response := MempoolResponse{
+			Stats:        bc.MempoolStats(),
+			Transactions: bc.TransactionPool(),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Printf("ERROR: Failed to encode response: %v", err)
+		}
+	default:
+		log.Printf("ERROR: Invalid HTTP Method: %s", req.Method)
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
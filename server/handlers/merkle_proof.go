@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *BlockchainServerHandler) MerkleProof(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		vars := mux.Vars(req)
+		w.Header().Set("Content-Type", "application/json")
+
+		b := h.server.GetBlockchain().GetBlockByHash(vars["hash"])
+		if b == nil {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"message": fmt.Sprintf("Block %s not found", vars["hash"])})
+			return
+		}
+
+		proof, err := b.MerkleProof(vars["txid"])
+		if err != nil {
+			log.Printf("ERROR: %v", err)
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"message": err.Error()})
+			return
+		}
+
+		if err := json.NewEncoder(w).Encode(proof); err != nil {
+			log.Printf("ERROR: Failed to encode response: %v", err)
+		}
+	default:
+		log.Printf("ERROR: Invalid HTTP Method: %s", req.Method)
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
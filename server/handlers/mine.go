@@ -1,17 +1,23 @@
 package handlers
 
 import (
+	"block/struct/amount"
 	"encoding/json"
 	"log"
 	"net/http"
 )
 
-const MINING_REWARD = 1.0
-
 type MineRequest struct {
 	MinerAddress string `json:"minerAddress"`
 }
 
+type MineResponse struct {
+	Message string        `json:"message"`
+	Height  uint64        `json:"height"`
+	Hash    string        `json:"hash"`
+	Reward  amount.Amount `json:"reward"`
+}
+
 func (h *BlockchainServerHandler) HandleMine(w http.ResponseWriter, req *http.Request) {
 	// Add CORS headers
 	w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -38,10 +44,7 @@ func (h *BlockchainServerHandler) HandleMine(w http.ResponseWriter, req *http.Re
 		return
 	}
 
-	if mineReq.MinerAddress == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Miner address is required"})
+	if !validateAddress(w, "minerAddress", mineReq.MinerAddress) {
 		return
 	}
 
@@ -49,7 +52,7 @@ func (h *BlockchainServerHandler) HandleMine(w http.ResponseWriter, req *http.Re
 	bc := h.server.GetBlockchain()
 
 	// Mine a new block
-	success, err := bc.MineBlock(mineReq.MinerAddress)
+	b, err := bc.MineBlock(mineReq.MinerAddress)
 	if err != nil {
 		log.Printf("ERROR: Mining failed: %v", err)
 		w.Header().Set("Content-Type", "application/json")
@@ -58,18 +61,19 @@ func (h *BlockchainServerHandler) HandleMine(w http.ResponseWriter, req *http.Re
 		return
 	}
 
-	if !success {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Mining failed"})
-		return
+	// Report the coinbase of the block just mined
+	var reward amount.Amount
+	if coinbase := b.Coinbase(); coinbase != nil {
+		reward = coinbase.GetValue()
 	}
 
 	// Return success response
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Block mined successfully",
-		"reward":  MINING_REWARD,
+	json.NewEncoder(w).Encode(MineResponse{
+		Message: "Block mined successfully",
+		Height:  b.GetHeight(),
+		Hash:    b.GetHash(),
+		Reward:  reward,
 	})
 }
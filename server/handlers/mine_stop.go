@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"block/struct/utils"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+)
+
+type MiningStatusResponse struct {
+	Mining bool `json:"mining"`
+}
+
+func (h *BlockchainServerHandler) StopMine(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		bc := h.server.GetBlockchain()
+		bc.StopMining()
+
+		m := utils.JsonStatus("success")
+		w.Header().Add("Content-Type", "application/json")
+		io.WriteString(w, string(m))
+	default:
+		log.Println("ERROR: Invalid HTTP Method")
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func (h *BlockchainServerHandler) MiningStatus(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		response := MiningStatusResponse{Mining: h.server.GetBlockchain().IsMining()}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Printf("ERROR: Failed to encode response: %v", err)
+		}
+	default:
+		log.Printf("ERROR: Invalid HTTP Method: %s", req.Method)
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
@@ -1,32 +1,39 @@
 package handlers
 
 import (
-	"bytes"
+	"block/struct/address"
+	"block/struct/utils"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"log"
 	"net/http"
 )
 
+// RegisterWalletResponse carries the private key only when the node generated
+// the key.
+type RegisterWalletResponse struct {
+	Address    string `json:"address"`
+	PublicKey  string `json:"public_key"`
+	PrivateKey string `json:"private_key,omitempty"`
+}
+
 func (h *BlockchainServerHandler) RegisterWallet(w http.ResponseWriter, req *http.Request) {
 	switch req.Method {
 	case http.MethodPost:
-		body, err := ioutil.ReadAll(req.Body)
+		body, err := io.ReadAll(req.Body)
 		if err != nil {
 			log.Printf("Failed to read request body: %v", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
-		req.Body = ioutil.NopCloser(bytes.NewBuffer(body))
 
 		type RequestBody struct {
 			BlockchainAddress *string `json:"blockchainAddress"`
+			PublicKey         *string `json:"publicKey"`
 		}
 
 		var requestBody RequestBody
@@ -37,50 +44,64 @@ func (h *BlockchainServerHandler) RegisterWallet(w http.ResponseWriter, req *htt
 			return
 		}
 
-		privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
-		if err != nil {
-			log.Printf("ERROR: Failed to generate private key: %v", err)
-			w.WriteHeader(http.StatusInternalServerError)
+		// Addresses are derived from public keys: register the key the client
+		// holds, or generate a new one
+		var publicKey *ecdsa.PublicKey
+		var privateKeyHex string
+		if requestBody.PublicKey != nil && *requestBody.PublicKey != "" {
+			publicKey, err = utils.PublicKeyFromString(*requestBody.PublicKey)
+			if err != nil {
+				log.Printf("ERROR: Invalid public key: %v", err)
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"message": fmt.Sprintf("Invalid public key: %v", err)})
+				return
+			}
+		} else if requestBody.BlockchainAddress != nil && *requestBody.BlockchainAddress != "" {
+			log.Printf("ERROR: Refusing to register address %s without its public key", *requestBody.BlockchainAddress)
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Addresses are derived from public keys, send publicKey instead of blockchainAddress"})
+			return
+		} else if h.server.Config().DisableKeyEndpoints {
+			log.Printf("ERROR: Refusing to generate a key, key endpoints are disabled")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]string{"message": "This node does not generate keys, send the publicKey of a key you hold"})
 			return
+		} else {
+			privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			if err != nil {
+				log.Printf("ERROR: Failed to generate private key: %v", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			publicKey = &privateKey.PublicKey
+			privateKeyHex = utils.PrivateKeyString(privateKey)
 		}
 
-		publicKey := privateKey.PublicKey
-		publicKeyBytes := elliptic.Marshal(elliptic.P256(), publicKey.X, publicKey.Y)
-		publicKeyHex := hex.EncodeToString(publicKeyBytes[1:]) // Skip leading 04
-		if len(publicKeyHex) != 128 {
-			log.Printf("ERROR: Generated public key length %d, expected 128", len(publicKeyHex))
-			w.WriteHeader(http.StatusInternalServerError)
+		publicKeyHex := utils.PublicKeyString(publicKey)
+		blockchainAddress := address.FromPublicKey(publicKey)
+		// An address sent along with the key must be the one it derives
+		if requestBody.BlockchainAddress != nil && *requestBody.BlockchainAddress != "" && *requestBody.BlockchainAddress != blockchainAddress {
+			log.Printf("ERROR: Address %s does not belong to public key %s", *requestBody.BlockchainAddress, publicKeyHex)
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"message": fmt.Sprintf("Address %s does not belong to the public key, which derives %s", *requestBody.BlockchainAddress, blockchainAddress)})
 			return
 		}
-		privateKeyHex := fmt.Sprintf("%064x", privateKey.D.Bytes())
 
-		var address string
-		if requestBody.BlockchainAddress != nil && *requestBody.BlockchainAddress != "" {
-			address = *requestBody.BlockchainAddress
-		} else {
-			hash := sha256.Sum256([]byte(publicKeyHex))
-			address = "1" + hex.EncodeToString(hash[:20])
-		}
-		success := h.server.GetBlockchain().RegisterNewWallet(address, "REGISTER USER WALLET")
+		success := h.server.GetBlockchain().RegisterNewWallet(blockchainAddress, "REGISTER USER WALLET")
 		if !success {
-			log.Printf("ERROR: Failed to register wallet with address: %s", address)
+			log.Printf("ERROR: Failed to register wallet with address: %s", blockchainAddress)
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 
-		response := struct {
-			Address    string `json:"address"`
-			PublicKey  string `json:"public_key"`
-			PrivateKey string `json:"private_key"`
-		}{
-			Address:    address,
+		response := RegisterWalletResponse{
+			Address:    blockchainAddress,
 			PublicKey:  publicKeyHex,
 			PrivateKey: privateKeyHex,
 		}
 
-		log.Printf("private_key %s", privateKeyHex)
 		log.Printf("public_key %s", publicKeyHex)
-		log.Printf("address %s", address)
+		log.Printf("address %s", blockchainAddress)
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
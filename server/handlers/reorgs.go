@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"block/struct/block"
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+func (h *BlockchainServerHandler) Reorgs(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		response := struct {
+			Reorgs []*block.ReorgEvent `json:"reorgs"`
+		}{
+			Reorgs: h.server.GetBlockchain().Reorgs(),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Printf("ERROR: Failed to encode response: %v", err)
+		}
+	default:
+		log.Printf("ERROR: Invalid HTTP Method: %s", req.Method)
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
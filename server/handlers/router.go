@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// NewRouter routes the node API to the handlers of a server. It carries no
+// middleware, so it can also be served in-process, for example by
+// httptest.NewServer.
+func NewRouter(s BlockchainServer) *mux.Router {
+	router := mux.NewRouter()
+	handler := NewBlockchainServerHandler(s)
+
+	router.HandleFunc("/chain", handler.GetChain)
+	router.HandleFunc("/accounts/{address}/nonce", handler.AccountNonce)
+	router.HandleFunc("/accounts/{address}/transactions", handler.AccountHistory)
+	router.HandleFunc("/balance", handler.Balance)
+	router.HandleFunc("/blocks/{id}", handler.GetBlock)
+	router.HandleFunc("/blocks/{hash}/proof/{txid}", handler.MerkleProof)
+	router.HandleFunc("/consensus", handler.Consensus)
+	router.HandleFunc("/fees/estimate", handler.FeeEstimate)
+	router.HandleFunc("/genesis", handler.Genesis)
+	router.HandleFunc("/mempool", handler.Mempool)
+	router.HandleFunc("/mine", handler.HandleMine)
+	router.HandleFunc("/mine/start", handler.StartMine)
+	router.HandleFunc("/mine/status", handler.MiningStatus)
+	router.HandleFunc("/mine/stop", handler.StopMine)
+	router.HandleFunc("/miner/blocks", handler.GetBlocks)
+	router.HandleFunc("/miner/wallet", handler.MinerWallet)
+	router.HandleFunc("/supply", handler.Supply)
+	router.HandleFunc("/transactions", handler.Transactions)
+	router.HandleFunc("/tx/{id}", handler.GetTransaction)
+	router.HandleFunc("/wallet/mnemonic", handler.CreateMnemonic)
+	router.HandleFunc("/wallet/next", handler.NextAddress)
+	router.HandleFunc("/wallet/register", handler.RegisterWallet)
+	router.HandleFunc("/wallet/restore", handler.RestoreMnemonic)
+	router.HandleFunc("/wallets", handler.GetWallets)
+	router.HandleFunc("/nodes", handler.GetNodes)
+	router.HandleFunc("/reorgs", handler.Reorgs)
+	router.HandleFunc("/reset", handler.Reset)
+	router.HandleFunc("/sign", handler.HandleSign).Methods(http.MethodPost)
+
+	return router
+}
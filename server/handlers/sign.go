@@ -1,11 +1,9 @@
 package handlers
 
 import (
-	"block/struct/block"
+	"block/struct/amount"
+	"block/struct/signing"
 	"block/struct/utils"
-	"crypto/ecdsa"
-	"crypto/rand"
-	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -13,19 +11,29 @@ import (
 )
 
 type SignRequest struct {
-	SenderBlockchainAddress    string  `json:"senderBlockchainAddress"`
-	RecipientBlockchainAddress string  `json:"recipientBlockchainAddress"`
-	Message                    string  `json:"message"`
-	Value                      float32 `json:"value"`
-	PrivateKey                 string  `json:"privateKey"`
-	PublicKey                  string  `json:"publicKey"`
+	ChainID                    string        `json:"chainId"`
+	SenderBlockchainAddress    string        `json:"senderBlockchainAddress"`
+	RecipientBlockchainAddress string        `json:"recipientBlockchainAddress"`
+	Message                    string        `json:"message"`
+	Value                      amount.Amount `json:"value"`
+	Fee                        amount.Amount `json:"fee"`
+	Nonce                      uint64        `json:"nonce"`
+	PrivateKey                 string        `json:"privateKey"`
+	PublicKey                  string        `json:"publicKey"`
 }
 
 type SignResponse struct {
+	ChainID   string `json:"chainId"`
 	Signature string `json:"signature"`
 }
 
+// HandleSign signs with a private key sent in the request. Nodes that
+// disable key endpoints refuse it; use cmd/signtx or the signing package.
 func (h *BlockchainServerHandler) HandleSign(w http.ResponseWriter, req *http.Request) {
+	h.requireKeyEndpoints(h.handleSign)(w, req)
+}
+
+func (h *BlockchainServerHandler) handleSign(w http.ResponseWriter, req *http.Request) {
 	if req.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -39,26 +47,12 @@ func (h *BlockchainServerHandler) HandleSign(w http.ResponseWriter, req *http.Re
 		return
 	}
 
-	if signReq.SenderBlockchainAddress == "" {
-		log.Printf("ERROR: Missing senderBlockchainAddress")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"message": "Missing senderBlockchainAddress"})
-		return
-	}
-	if signReq.RecipientBlockchainAddress == "" {
-		log.Printf("ERROR: Missing recipientBlockchainAddress")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"message": "Missing recipientBlockchainAddress"})
+	if !validateAddress(w, "senderBlockchainAddress", signReq.SenderBlockchainAddress) ||
+		!validateAddress(w, "recipientBlockchainAddress", signReq.RecipientBlockchainAddress) {
 		return
 	}
-	if signReq.Message == "" {
-		log.Printf("ERROR: Missing message")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"message": "Missing message"})
-		return
-	}
-	if signReq.Value <= 0 {
-		log.Printf("ERROR: Invalid value: %f", signReq.Value)
+	if signReq.Value == 0 {
+		log.Printf("ERROR: Invalid value: %s", signReq.Value)
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"message": "Invalid value"})
 		return
@@ -76,18 +70,29 @@ func (h *BlockchainServerHandler) HandleSign(w http.ResponseWriter, req *http.Re
 		return
 	}
 
-	log.Printf("Signing transaction: sender=%s, recipient=%s, message=%s, value=%f",
-		signReq.SenderBlockchainAddress,
-		signReq.RecipientBlockchainAddress,
-		signReq.Message,
-		signReq.Value)
+	// Sign for this node's chain unless told otherwise
+	if signReq.ChainID == "" {
+		signReq.ChainID = h.server.GetBlockchain().Genesis().ChainID
+	}
 
-	tx := block.NewTransaction(
+	log.Printf("Signing transaction: chain=%s, sender=%s, recipient=%s, message=%s, value=%s, fee=%s, nonce=%d",
+		signReq.ChainID,
 		signReq.SenderBlockchainAddress,
 		signReq.RecipientBlockchainAddress,
 		signReq.Message,
 		signReq.Value,
-	)
+		signReq.Fee,
+		signReq.Nonce)
+
+	payload := &signing.Payload{
+		ChainID:   signReq.ChainID,
+		Sender:    signReq.SenderBlockchainAddress,
+		Recipient: signReq.RecipientBlockchainAddress,
+		Value:     signReq.Value,
+		Fee:       signReq.Fee,
+		Nonce:     signReq.Nonce,
+		Message:   signReq.Message,
+	}
 
 	publicKey, err := utils.PublicKeyFromString(signReq.PublicKey)
 	if err != nil {
@@ -105,19 +110,7 @@ func (h *BlockchainServerHandler) HandleSign(w http.ResponseWriter, req *http.Re
 		return
 	}
 
-	m, err := json.Marshal(tx)
-	if err != nil {
-		log.Printf("ERROR: Failed to marshal transaction: %v", err)
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"message": fmt.Sprintf("Failed to marshal transaction: %v", err)})
-		return
-	}
-	log.Printf("Sign: Marshaled transaction: %s", string(m))
-
-	hash := sha256.Sum256(m)
-	log.Printf("Sign: Transaction hash: %x", hash)
-
-	r, s, err := ecdsa.Sign(rand.Reader, privateKey, hash[:])
+	signature, err := signing.Sign(privateKey, payload)
 	if err != nil {
 		log.Printf("ERROR: Failed to sign transaction: %v", err)
 		w.WriteHeader(http.StatusBadRequest)
@@ -125,13 +118,7 @@ func (h *BlockchainServerHandler) HandleSign(w http.ResponseWriter, req *http.Re
 		return
 	}
 
-	signature := &utils.Signature{R: r, S: s}
-
-	log.Printf("Generated signature R: %x", r)
-	log.Printf("Generated signature S: %x", s)
-	log.Printf("Generated signature string: %s", signature.String())
-
-	valid := ecdsa.Verify(&privateKey.PublicKey, hash[:], r, s)
+	valid := signing.Verify(&privateKey.PublicKey, payload, signature)
 	if !valid {
 		log.Printf("ERROR: Generated signature failed immediate verification")
 		w.WriteHeader(http.StatusInternalServerError)
@@ -142,5 +129,5 @@ func (h *BlockchainServerHandler) HandleSign(w http.ResponseWriter, req *http.Re
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(SignResponse{Signature: signature.String()})
+	json.NewEncoder(w).Encode(SignResponse{ChainID: signReq.ChainID, Signature: signature.String()})
 }
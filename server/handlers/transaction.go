@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"block/struct/amount"
 	"block/struct/block"
 	"block/struct/utils"
 	"encoding/json"
@@ -11,12 +12,15 @@ import (
 )
 
 type TransactionRequest struct {
-	SenderBlockchainAddress    string  `json:"senderBlockchainAddress"`
-	RecipientBlockchainAddress string  `json:"recipientBlockchainAddress"`
-	Message                    string  `json:"message"`
-	Value                      float32 `json:"value"`
-	SenderPublicKey            string  `json:"senderPublicKey"`
-	Signature                  string  `json:"signature"`
+	ChainID                    string        `json:"chainId"`
+	SenderBlockchainAddress    string        `json:"senderBlockchainAddress"`
+	RecipientBlockchainAddress string        `json:"recipientBlockchainAddress"`
+	Message                    string        `json:"message"`
+	Value                      amount.Amount `json:"value"`
+	Fee                        amount.Amount `json:"fee"`
+	Nonce                      uint64        `json:"nonce"`
+	SenderPublicKey            string        `json:"senderPublicKey"`
+	Signature                  string        `json:"signature"`
 }
 
 func (h *BlockchainServerHandler) HandleGetTransaction(w http.ResponseWriter, req *http.Request) {
@@ -49,14 +53,20 @@ func (h *BlockchainServerHandler) HandlePostTransaction(w http.ResponseWriter, r
 		return
 	}
 
+	// The message is optional
 	if txReq.SenderBlockchainAddress == "" || txReq.RecipientBlockchainAddress == "" ||
-		txReq.Message == "" || txReq.Value <= 0 || txReq.SenderPublicKey == "" || txReq.Signature == "" {
+		txReq.Value == 0 || txReq.SenderPublicKey == "" || txReq.Signature == "" {
 		log.Printf("ERROR: Missing required fields")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"message": "Missing required fields"})
 		return
 	}
 
+	if !validateAddress(w, "senderBlockchainAddress", txReq.SenderBlockchainAddress) ||
+		!validateAddress(w, "recipientBlockchainAddress", txReq.RecipientBlockchainAddress) {
+		return
+	}
+
 	publicKey, err := utils.PublicKeyFromString(txReq.SenderPublicKey)
 	if err != nil {
 		log.Printf("ERROR: Invalid public key: %v", err)
@@ -74,42 +84,32 @@ func (h *BlockchainServerHandler) HandlePostTransaction(w http.ResponseWriter, r
 	}
 
 	bc := h.server.GetBlockchain()
-	success, err := bc.AddTransaction(
+	id, err := bc.AddTransaction(
+		txReq.ChainID,
 		txReq.SenderBlockchainAddress,
 		txReq.RecipientBlockchainAddress,
 		txReq.Message,
 		txReq.Value,
+		txReq.Fee,
+		txReq.Nonce,
 		publicKey,
 		signature,
 	)
 
-	if !success {
+	if err != nil {
 		log.Printf("ERROR: Failed to create transaction: %v", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"message": fmt.Sprintf("Failed to create transaction: %v", err)})
 		return
 	}
 
-	// Mine the block with the transaction
-	success, err = bc.MineBlock(txReq.SenderBlockchainAddress)
-	if err != nil {
-		log.Printf("ERROR: Failed to mine block: %v", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"message": fmt.Sprintf("Failed to mine block: %v", err)})
-		return
-	}
-
-	if !success {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"message": "Failed to mine block"})
-		return
-	}
-
+	// The transaction waits in the pool for the node's miner
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]string{
-		"message": "Transaction created and mined successfully",
+		"message": "Transaction added to the pool",
 		"status":  "success",
+		"id":      id,
 	})
 }
 
@@ -131,6 +131,11 @@ func (h *BlockchainServerHandler) HandlePutTransaction(w http.ResponseWriter, re
 		return
 	}
 
+	if !validateAddress(w, "senderBlockchainAddress", *t.SenderBlockchainAddress) ||
+		!validateAddress(w, "recipientBlockchainAddress", *t.RecipientBlockchainAddress) {
+		return
+	}
+
 	log.Printf("Received senderPublicKey: %s", *t.SenderPublicKey)
 	log.Printf("Received signature: %s", *t.Signature)
 
@@ -150,20 +155,31 @@ func (h *BlockchainServerHandler) HandlePutTransaction(w http.ResponseWriter, re
 		return
 	}
 
+	var fee amount.Amount
+	if t.Fee != nil {
+		fee = *t.Fee
+	}
+	var chainID string
+	if t.ChainID != nil {
+		chainID = *t.ChainID
+	}
+
 	bc := h.server.GetBlockchain()
 
-	isUpdated, err := bc.AddTransaction(
+	id, err := bc.AddTransaction(
+		chainID,
 		*t.SenderBlockchainAddress,
 		*t.RecipientBlockchainAddress,
 		*t.Message,
 		*t.Value,
+		fee,
+		*t.Nonce,
 		publicKey,
 		signature,
 	)
 
 	w.Header().Add("Content-Type", "application/json")
 
-	var m []byte
 	if err != nil {
 		log.Printf("ERROR: Failed to add transaction: %v", err)
 		w.WriteHeader(http.StatusBadRequest)
@@ -171,22 +187,7 @@ func (h *BlockchainServerHandler) HandlePutTransaction(w http.ResponseWriter, re
 		return
 	}
 
-	if !isUpdated {
-		w.WriteHeader(http.StatusBadRequest)
-		m = utils.JsonStatus("fail")
-	} else {
-		m = utils.JsonStatus("success")
-	}
-
-	io.WriteString(w, string(m))
-}
-
-func (h *BlockchainServerHandler) HandleDeleteTransaction(w http.ResponseWriter, req *http.Request) {
-	bc := h.server.GetBlockchain()
-
-	bc.ClearTransactionPool()
-
-	io.WriteString(w, string(utils.JsonStatus("success")))
+	json.NewEncoder(w).Encode(map[string]string{"message": "success", "id": id})
 }
 
 func (h *BlockchainServerHandler) Transactions(w http.ResponseWriter, req *http.Request) {
@@ -197,8 +198,6 @@ func (h *BlockchainServerHandler) Transactions(w http.ResponseWriter, req *http.
 		h.HandlePostTransaction(w, req)
 	case http.MethodPut:
 		h.HandlePutTransaction(w, req)
-	case http.MethodDelete:
-		h.HandleDeleteTransaction(w, req)
 	default:
 		log.Println("ERROR: Invalid HTTP Method")
 		w.WriteHeader(http.StatusBadRequest)
@@ -4,7 +4,7 @@
 ports=(5001 5002 5003)
 
 for port in "${ports[@]}"; do
-    PORT=$port go run main.go &
+    go run . -port $port -neighbor-ports 5001-5003 &
     echo "Started node on port $port"
     sleep 2  # Wait between starting nodes
 done
@@ -0,0 +1,124 @@
+// Package address derives, encodes and parses blockchain addresses. An
+// address is the base58 encoding of a version byte, the RIPEMD-160 of the
+// SHA-256 of a public key, and a 4-byte checksum: the first bytes of the
+// double SHA-256 of the version and hash.
+package address
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcutil/base58"
+	"golang.org/x/crypto/ripemd160"
+)
+
+// VERSION is the version byte of every address.
+const VERSION byte = 0x00
+
+// Sizes of the decoded parts of an address.
+const (
+	HASH_SIZE     = ripemd160.Size
+	CHECKSUM_SIZE = 4
+	DECODED_SIZE  = 1 + HASH_SIZE + CHECKSUM_SIZE
+)
+
+var (
+	ErrEmpty    = errors.New("address is empty")
+	ErrEncoding = errors.New("not base58 encoded")
+	ErrLength   = errors.New("wrong length")
+	ErrVersion  = errors.New("unknown version")
+	ErrChecksum = errors.New("checksum mismatch")
+)
+
+// Error reports an address that failed to parse. Err is one of the errors
+// above.
+type Error struct {
+	Address string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err == ErrEmpty {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("invalid address %q: %v", e.Address, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Hash is the public key hash an address encodes.
+type Hash [HASH_SIZE]byte
+
+// HashPublicKey returns the hash of a public key. The key is hashed as its X
+// and Y coordinates, each padded to 32 bytes.
+func HashPublicKey(publicKey *ecdsa.PublicKey) Hash {
+	key := make([]byte, 64)
+	publicKey.X.FillBytes(key[:32])
+	publicKey.Y.FillBytes(key[32:])
+
+	digest := sha256.Sum256(key)
+	h := ripemd160.New()
+	h.Write(digest[:])
+
+	var hash Hash
+	copy(hash[:], h.Sum(nil))
+	return hash
+}
+
+func checksum(payload []byte) []byte {
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	return second[:CHECKSUM_SIZE]
+}
+
+// Encode returns the address of a public key hash.
+func Encode(hash Hash) string {
+	payload := append([]byte{VERSION}, hash[:]...)
+	return base58.Encode(append(payload, checksum(payload)...))
+}
+
+// Parse decodes an address, checking its length, version and checksum, and
+// returns the public key hash. Errors are of type *Error.
+func Parse(s string) (Hash, error) {
+	var hash Hash
+	if s == "" {
+		return hash, &Error{Address: s, Err: ErrEmpty}
+	}
+	decoded := base58.Decode(s)
+	if len(decoded) == 0 {
+		return hash, &Error{Address: s, Err: ErrEncoding}
+	}
+	if len(decoded) != DECODED_SIZE {
+		return hash, &Error{Address: s, Err: ErrLength}
+	}
+	if decoded[0] != VERSION {
+		return hash, &Error{Address: s, Err: ErrVersion}
+	}
+	payload := decoded[:1+HASH_SIZE]
+	if !bytes.Equal(checksum(payload), decoded[1+HASH_SIZE:]) {
+		return hash, &Error{Address: s, Err: ErrChecksum}
+	}
+	copy(hash[:], payload[1:])
+	return hash, nil
+}
+
+// Validate reports why s is not a well-formed address, or nil if it is.
+func Validate(s string) error {
+	_, err := Parse(s)
+	return err
+}
+
+// FromPublicKey returns the address of a public key.
+func FromPublicKey(publicKey *ecdsa.PublicKey) string {
+	return Encode(HashPublicKey(publicKey))
+}
+
+// MatchesPublicKey reports whether the address was derived from the key.
+func MatchesPublicKey(address string, publicKey *ecdsa.PublicKey) bool {
+	return publicKey != nil && FromPublicKey(publicKey) == address
+}
@@ -0,0 +1,106 @@
+package address
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcutil/base58"
+)
+
+// encodeRaw base58-encodes a version and payload with a valid checksum.
+func encodeRaw(version byte, payload []byte) string {
+	data := append([]byte{version}, payload...)
+	return base58.Encode(append(data, checksum(data)...))
+}
+
+func TestEncode(t *testing.T) {
+	// Version 0 addresses share their encoding with Bitcoin's P2PKH
+	tests := map[string]string{
+		"0000000000000000000000000000000000000000": "1111111111111111111114oLvT2",
+		"010966776006953d5567439e5e39f86a0d273bee": "16UwLL9Risc3QfPqBUvKofHmBQ7wMtjvM",
+	}
+	for hexHash, want := range tests {
+		var hash Hash
+		decoded, _ := hex.DecodeString(hexHash)
+		copy(hash[:], decoded)
+		if got := Encode(hash); got != want {
+			t.Errorf("Encode(%s) = %s, want %s", hexHash, got, want)
+		}
+		parsed, err := Parse(want)
+		if err != nil {
+			t.Errorf("Parse(%s) = %v", want, err)
+		} else if parsed != hash {
+			t.Errorf("Parse(%s) = %x, want %s", want, parsed, hexHash)
+		}
+	}
+}
+
+func TestFromPublicKey(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := FromPublicKey(&privateKey.PublicKey)
+	if err := Validate(a); err != nil {
+		t.Fatalf("Validate(%s) = %v", a, err)
+	}
+	if !MatchesPublicKey(a, &privateKey.PublicKey) {
+		t.Fatal("address does not match its own key")
+	}
+	if MatchesPublicKey(a, &other.PublicKey) || MatchesPublicKey(a, nil) {
+		t.Fatal("address matches another key")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	valid := encodeRaw(VERSION, bytes.Repeat([]byte{0xab}, HASH_SIZE))
+	corrupted := []byte(valid)
+	if corrupted[len(corrupted)-1] == 'z' {
+		corrupted[len(corrupted)-1] = 'y'
+	} else {
+		corrupted[len(corrupted)-1] = 'z'
+	}
+
+	tests := []struct {
+		name    string
+		address string
+		want    error
+	}{
+		{"empty", "", ErrEmpty},
+		{"not base58", "0OIl", ErrEncoding},
+		{"checksum", string(corrupted), ErrChecksum},
+		{"version", encodeRaw(0x05, bytes.Repeat([]byte{0xab}, HASH_SIZE)), ErrVersion},
+		{"short", encodeRaw(VERSION, bytes.Repeat([]byte{0xab}, HASH_SIZE-1)), ErrLength},
+		{"long", encodeRaw(VERSION, bytes.Repeat([]byte{0xab}, HASH_SIZE+1)), ErrLength},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.address)
+		var addrErr *Error
+		if !errors.As(err, &addrErr) {
+			t.Errorf("%s: Parse(%q) error = %v, want an *Error", tt.name, tt.address, err)
+			continue
+		}
+		if addrErr.Address != tt.address || addrErr.Err != tt.want || !errors.Is(err, tt.want) {
+			t.Errorf("%s: Parse(%q) error = %+v, want %v", tt.name, tt.address, addrErr, tt.want)
+		}
+		if Validate(tt.address) == nil {
+			t.Errorf("%s: Validate(%q) accepted it", tt.name, tt.address)
+		}
+	}
+
+	if msg := (&Error{Err: ErrEmpty}).Error(); msg != ErrEmpty.Error() {
+		t.Errorf("empty address message = %q", msg)
+	}
+	if msg := (&Error{Address: "abc", Err: ErrLength}).Error(); msg != `invalid address "abc": wrong length` {
+		t.Errorf("wrong length message = %q", msg)
+	}
+}
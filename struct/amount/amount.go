@@ -0,0 +1,158 @@
+// Package amount counts coins in integer base units with checked arithmetic.
+// Amounts travel as decimal strings of coins with at most DECIMALS fractional
+// digits.
+//
+// The number of decimals is the same on every network rather than a genesis
+// parameter: the genesis file, the API and clients parse decimal strings
+// before they know which network they are on, so a per-network precision
+// would let the same text mean different amounts. The chain state and the
+// signing encoding count base units and do not depend on it.
+package amount
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// DECIMALS is the number of decimal places of one coin on every network.
+const DECIMALS = 8
+
+// UNIT is the number of base units in one coin.
+const UNIT = 100000000
+
+var (
+	ErrOverflow = errors.New("amount overflows")
+	ErrNegative = errors.New("amount would be negative")
+	ErrInvalid  = errors.New("invalid amount")
+)
+
+// Amount is a non-negative quantity of coins counted in indivisible base
+// units. One coin is UNIT base units.
+type Amount uint64
+
+// FromCoins converts a whole number of coins into an Amount.
+func FromCoins(coins uint64) (Amount, error) {
+	hi, lo := bits.Mul64(coins, UNIT)
+	if hi != 0 {
+		return 0, ErrOverflow
+	}
+	return Amount(lo), nil
+}
+
+// MustFromCoins is FromCoins for values known to fit, such as constants.
+func MustFromCoins(coins uint64) Amount {
+	a, err := FromCoins(coins)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// Parse reads a decimal coin string such as "12" or "0.25". It rejects
+// signs, exponents and more fractional digits than DECIMALS.
+func Parse(s string) (Amount, error) {
+	whole, frac, hasPoint := strings.Cut(s, ".")
+	if whole == "" || (hasPoint && frac == "") {
+		return 0, fmt.Errorf("%w: %q", ErrInvalid, s)
+	}
+	if len(frac) > DECIMALS {
+		return 0, fmt.Errorf("%w: %q has more than %d decimals", ErrInvalid, s, DECIMALS)
+	}
+	for _, part := range []string{whole, frac} {
+		for _, c := range part {
+			if c < '0' || c > '9' {
+				return 0, fmt.Errorf("%w: %q", ErrInvalid, s)
+			}
+		}
+	}
+
+	w, err := strconv.ParseUint(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrOverflow, s)
+	}
+	a, err := FromCoins(w)
+	if err != nil {
+		return 0, err
+	}
+	if frac == "" {
+		return a, nil
+	}
+	f, _ := strconv.ParseUint(frac+strings.Repeat("0", DECIMALS-len(frac)), 10, 64)
+	return a.Add(Amount(f))
+}
+
+// String formats the amount as a decimal coin string without trailing
+// fractional zeros.
+func (a Amount) String() string {
+	whole := uint64(a) / UNIT
+	frac := uint64(a) % UNIT
+	if frac == 0 {
+		return strconv.FormatUint(whole, 10)
+	}
+	f := fmt.Sprintf("%0*d", DECIMALS, frac)
+	return strconv.FormatUint(whole, 10) + "." + strings.TrimRight(f, "0")
+}
+
+// Add returns a+b, or ErrOverflow.
+func (a Amount) Add(b Amount) (Amount, error) {
+	if uint64(a) > math.MaxUint64-uint64(b) {
+		return 0, ErrOverflow
+	}
+	return a + b, nil
+}
+
+// Sub returns a-b, or ErrNegative if b exceeds a.
+func (a Amount) Sub(b Amount) (Amount, error) {
+	if b > a {
+		return 0, ErrNegative
+	}
+	return a - b, nil
+}
+
+// MulInt returns a*n, or ErrOverflow.
+func (a Amount) MulInt(n uint64) (Amount, error) {
+	hi, lo := bits.Mul64(uint64(a), n)
+	if hi != 0 {
+		return 0, ErrOverflow
+	}
+	return Amount(lo), nil
+}
+
+// Sum adds all amounts, or returns ErrOverflow.
+func Sum(amounts ...Amount) (Amount, error) {
+	var total Amount
+	var err error
+	for _, a := range amounts {
+		if total, err = total.Add(a); err != nil {
+			return 0, err
+		}
+	}
+	return total, nil
+}
+
+// MarshalJSON encodes the amount as a decimal string.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(a.String())), nil
+}
+
+// UnmarshalJSON accepts a decimal string. Bare JSON numbers are read from
+// their literal text so no precision is lost to floating point.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	v, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
@@ -0,0 +1,118 @@
+package amount
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Amount
+		err  error
+	}{
+		{"0", 0, nil},
+		{"12", 12 * UNIT, nil},
+		{"0.25", UNIT / 4, nil},
+		{"1.00000001", UNIT + 1, nil},
+		{"007", 7 * UNIT, nil},
+		{"1.", 0, ErrInvalid},
+		{".5", 0, ErrInvalid},
+		{"", 0, ErrInvalid},
+		{".", 0, ErrInvalid},
+		{"0.000000001", 0, ErrInvalid},
+		{"-1", 0, ErrInvalid},
+		{"+1", 0, ErrInvalid},
+		{"1e3", 0, ErrInvalid},
+		{"1.2.3", 0, ErrInvalid},
+		{" 1", 0, ErrInvalid},
+		{"184467440737.09551615", math.MaxUint64, nil},
+		{"184467440737.09551616", 0, ErrOverflow},
+		{"184467440738", 0, ErrOverflow},
+		{"18446744073709551616", 0, ErrOverflow},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Parse(%q) error = %v, want %v", tt.in, err, tt.err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		in   Amount
+		want string
+	}{
+		{0, "0"},
+		{UNIT, "1"},
+		{UNIT / 4, "0.25"},
+		{UNIT + 1, "1.00000001"},
+		{math.MaxUint64, "184467440737.09551615"},
+	}
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("Amount(%d).String() = %q, want %q", uint64(tt.in), got, tt.want)
+		}
+		if back, err := Parse(tt.want); err != nil || back != tt.in {
+			t.Errorf("Parse(%q) = %d, %v, want %d", tt.want, back, err, uint64(tt.in))
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	if got, err := Amount(2).Add(3); err != nil || got != 5 {
+		t.Errorf("2+3 = %d, %v", got, err)
+	}
+	if _, err := Amount(math.MaxUint64).Add(1); !errors.Is(err, ErrOverflow) {
+		t.Errorf("MaxUint64+1 error = %v, want ErrOverflow", err)
+	}
+	if got, err := Amount(math.MaxUint64 - 1).Add(1); err != nil || got != math.MaxUint64 {
+		t.Errorf("(MaxUint64-1)+1 = %d, %v", got, err)
+	}
+
+	if got, err := Amount(5).Sub(5); err != nil || got != 0 {
+		t.Errorf("5-5 = %d, %v", got, err)
+	}
+	if _, err := Amount(2).Sub(3); !errors.Is(err, ErrNegative) {
+		t.Errorf("2-3 error = %v, want ErrNegative", err)
+	}
+
+	if got, err := Amount(UNIT).MulInt(3); err != nil || got != 3*UNIT {
+		t.Errorf("1*3 = %d, %v", got, err)
+	}
+	if _, err := Amount(math.MaxUint64/2 + 1).MulInt(2); !errors.Is(err, ErrOverflow) {
+		t.Errorf("(MaxUint64/2+1)*2 error = %v, want ErrOverflow", err)
+	}
+	if got, err := Amount(math.MaxUint64).MulInt(0); err != nil || got != 0 {
+		t.Errorf("MaxUint64*0 = %d, %v", got, err)
+	}
+
+	if _, err := Sum(math.MaxUint64, 1); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Sum overflow error = %v, want ErrOverflow", err)
+	}
+	if _, err := FromCoins(math.MaxUint64/UNIT + 1); !errors.Is(err, ErrOverflow) {
+		t.Errorf("FromCoins overflow error = %v, want ErrOverflow", err)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var a Amount
+	for _, in := range []string{`"1.5"`, `1.5`} {
+		if err := a.UnmarshalJSON([]byte(in)); err != nil || a != UNIT+UNIT/2 {
+			t.Errorf("UnmarshalJSON(%s) = %d, %v", in, a, err)
+		}
+	}
+	if err := a.UnmarshalJSON([]byte(`"1.123456789"`)); !errors.Is(err, ErrInvalid) {
+		t.Errorf("UnmarshalJSON too many decimals error = %v, want ErrInvalid", err)
+	}
+	data, err := Amount(UNIT + UNIT/2).MarshalJSON()
+	if err != nil || string(data) != `"1.5"` {
+		t.Errorf("MarshalJSON = %s, %v", data, err)
+	}
+}
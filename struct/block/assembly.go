@@ -0,0 +1,81 @@
+package block
+
+import (
+	"block/struct/amount"
+	"log"
+	"math/bits"
+	"sort"
+)
+
+// COINBASE_RESERVED_SIZE is the part of the block size limit kept free for
+// the coinbase while selecting transactions.
+const COINBASE_RESERVED_SIZE = 512
+
+// FeeRate returns the fee per byte of encoded transaction, in base units.
+func (t *Transaction) FeeRate() uint64 {
+	size := t.Size()
+	if size == 0 {
+		return 0
+	}
+	return uint64(t.fee) / uint64(size)
+}
+
+// higherFeeRate reports whether a pays more per byte than b, comparing
+// fee(a)*size(b) with fee(b)*size(a) exactly.
+func higherFeeRate(a *Transaction, b *Transaction) bool {
+	ahi, alo := bits.Mul64(uint64(a.fee), uint64(b.Size()))
+	bhi, blo := bits.Mul64(uint64(b.fee), uint64(a.Size()))
+	return ahi > bhi || (ahi == bhi && alo > blo)
+}
+
+// selectTransactions picks pooled transactions for a block, highest fee rate
+// first, until sizeLimit is reached. Transfers are applied to state as they
+// are picked, so a sender's later nonces are picked in a later pass once the
+// earlier ones are in. It returns the picked transactions and the sum of
+// their fees.
+//
+// Transactions left out stay in the pool.
+func selectTransactions(state *chainState, pool []*Transaction, sizeLimit int) ([]*Transaction, amount.Amount) {
+	candidates := make([]*Transaction, 0, len(pool))
+	for _, t := range pool {
+		if t.senderBlockchainAddress != MINING_SENDER {
+			candidates = append(candidates, t)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return higherFeeRate(candidates[i], candidates[j])
+	})
+
+	var selected []*Transaction
+	var fees amount.Amount
+	size := 0
+	for progress := true; progress && len(candidates) > 0; {
+		progress = false
+		remaining := candidates[:0]
+		for _, t := range candidates {
+			txSize := t.Size()
+			if size+txSize > sizeLimit {
+				remaining = append(remaining, t)
+				continue
+			}
+			// Check the fee total before touching the state, so a skipped
+			// transaction leaves no trace in it
+			total, err := fees.Add(t.fee)
+			if err != nil {
+				log.Printf("WARNING: Skipping transaction %s: %v", t.ID(), err)
+				continue
+			}
+			if err := state.applyTransfer(t); err != nil {
+				remaining = append(remaining, t)
+				continue
+			}
+			fees = total
+			size += txSize
+			selected = append(selected, t)
+			progress = true
+		}
+		candidates = remaining
+	}
+
+	return selected, fees
+}
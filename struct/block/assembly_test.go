@@ -0,0 +1,61 @@
+package block
+
+import (
+	"block/struct/amount"
+	"testing"
+)
+
+func TestSelectTransactionsFeeRate(t *testing.T) {
+	g := testGenesis()
+	state := newChainState(g.ChainID, g.Params)
+	a, from := newTestKey(t)
+	state.balances[from] = amount.MustFromCoins(10)
+	to := newTestAddress(t)
+
+	cheap := signedTransfer(t, a, g.ChainID, to, 1, 10, 0)
+	rich := signedTransfer(t, a, g.ChainID, to, 1, 1000, 1)
+	b, other := newTestKey(t)
+	state.balances[other] = amount.MustFromCoins(10)
+	middle := signedTransfer(t, b, g.ChainID, to, 1, 500, 0)
+
+	// rich pays the most but needs cheap first, which only fits in the
+	// second pass
+	selected, fees := selectTransactions(state, []*Transaction{cheap, rich, middle}, 1<<20)
+	if len(selected) != 3 || selected[0] != middle || selected[1] != cheap || selected[2] != rich || fees != 1510 {
+		t.Fatalf("selected %d transactions with %s in fees, want middle, cheap, rich and 1510", len(selected), fees)
+	}
+
+	// The size limit leaves room for one transaction
+	state = newChainState(g.ChainID, g.Params)
+	state.balances[from] = amount.MustFromCoins(10)
+	state.balances[other] = amount.MustFromCoins(10)
+	selected, _ = selectTransactions(state, []*Transaction{cheap, middle}, middle.Size())
+	if len(selected) != 1 || selected[0] != middle {
+		t.Fatalf("selected %d transactions under the size limit, want only middle", len(selected))
+	}
+}
+
+func TestSelectTransactionsFeeOverflow(t *testing.T) {
+	g := testGenesis()
+	state := newChainState(g.ChainID, g.Params)
+	to := newTestAddress(t)
+	half := amount.Amount(1 << 63)
+
+	x, xAddress := newTestKey(t)
+	y, yAddress := newTestKey(t)
+	state.balances[xAddress] = half + 10
+	state.balances[yAddress] = half + 10
+	first := signedTransfer(t, x, g.ChainID, to, 1, half, 0)
+	overflow := signedTransfer(t, y, g.ChainID, to, 1, half, 0)
+	next := signedTransfer(t, y, g.ChainID, to, 1, 1, 1)
+
+	// overflow is skipped since the fees would not fit in an amount; its
+	// transfer must not reach the state, or next would follow a missing nonce
+	selected, fees := selectTransactions(state, []*Transaction{first, overflow, next}, 1<<20)
+	if len(selected) != 1 || selected[0] != first || fees != half {
+		t.Fatalf("selected %d transactions with %s in fees, want only the first", len(selected), fees)
+	}
+	if state.Nonce(yAddress) != 0 || state.Balance(yAddress) != half+10 {
+		t.Fatalf("skipped sender has nonce %d and balance %s, want 0 and %s", state.Nonce(yAddress), state.Balance(yAddress), half+10)
+	}
+}
@@ -0,0 +1,53 @@
+package block
+
+import "fmt"
+
+// CalculateTotalBalance reports the funds of an address: the confirmed
+// balance it can spend, coinbase rewards that are not mature yet, and the
+// amounts pooled transactions will move in and out.
+func (bc *Blockchain) CalculateTotalBalance(blockchainAddress string) (*BalanceResponse, error) {
+	bc.mux.Lock()
+	defer bc.mux.Unlock()
+
+	if bc.state == nil {
+		return nil, fmt.Errorf("current chain state is unavailable")
+	}
+	if !bc.state.Known(blockchainAddress) {
+		return nil, fmt.Errorf("Address not found in the Blockchain")
+	}
+
+	br := &BalanceResponse{Balance: bc.state.Balance(blockchainAddress)}
+	var err error
+	if br.Immature, err = bc.state.Immature(blockchainAddress); err != nil {
+		return nil, fmt.Errorf("balance of %s: %v", blockchainAddress, err)
+	}
+	if br.PendingOutgoing, err = bc.pool.PendingSpend(blockchainAddress); err != nil {
+		return nil, fmt.Errorf("balance of %s: %v", blockchainAddress, err)
+	}
+	for _, t := range bc.TransactionPool() {
+		if t.recipientBlockchainAddress != blockchainAddress {
+			continue
+		}
+		if br.PendingIncoming, err = br.PendingIncoming.Add(t.value); err != nil {
+			return nil, fmt.Errorf("balance of %s: %v", blockchainAddress, err)
+		}
+	}
+	return br, nil
+}
+
+// AddressUsed reports whether the address has appeared on the chain or in a
+// pooled transaction.
+func (bc *Blockchain) AddressUsed(blockchainAddress string) bool {
+	bc.mux.Lock()
+	defer bc.mux.Unlock()
+
+	if bc.state != nil && bc.state.Known(blockchainAddress) {
+		return true
+	}
+	for _, t := range bc.TransactionPool() {
+		if t.senderBlockchainAddress == blockchainAddress || t.recipientBlockchainAddress == blockchainAddress {
+			return true
+		}
+	}
+	return false
+}
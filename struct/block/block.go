@@ -14,50 +14,89 @@ import (
 
 // Mining related constants.
 const (
-	MINING_DIFFICULTY = 3
 	MINING_SENDER     = "THE BLOCKCHAIN"
-	MINING_REWARD     = 1.0
-	MINING_TIMER_SEC  = 20
+	COINBASE_MATURITY = 10 // blocks
+
+	CONSENSUS_NOTIFY_TIMEOUT_SEC = 5
+	CONSENSUS_FETCH_TIMEOUT_SEC  = 30
+)
+
+// Emission related constants. The subsidy halves every
+// MINING_HALVING_INTERVAL blocks, so total emission converges on twice the
+// initial subsidy times the interval; MAX_SUPPLY is a hard cap on top of that.
+const (
+	MINING_INITIAL_SUBSIDY  = 1 // whole coins
+	MINING_HALVING_INTERVAL = 100000
+	MAX_SUPPLY              = 2 * MINING_INITIAL_SUBSIDY * MINING_HALVING_INTERVAL // whole coins
+)
+
+// Difficulty related constants. Difficulties count leading zero bits of the
+// block hash.
+const (
+	MINING_DIFFICULTY            = 12
+	MINING_MIN_DIFFICULTY        = 4
+	MINING_MAX_DIFFICULTY        = 32
+	MINING_TARGET_BLOCK_TIME_SEC = 20
+	MINING_RETARGET_INTERVAL     = 10
+	MINING_MAX_RETARGET_STEP     = 2
+	MAX_FUTURE_BLOCK_TIME_SEC    = 2 * 60 * 60
 )
 
-// Network related constants.
+// Block assembly related constants.
 const (
-	BLOCKCHAIN_PORT_RANGE_START      = 5001
-	BLOCKCHAIN_PORT_RANGE_END        = 5002
-	NEIGHBOR_IP_RANGE_START          = 0
-	NEIGHBOR_IP_RANGE_END            = 1
-	BLOCKCHIN_NEIGHBOR_SYNC_TIME_SEC = 20
+	MAX_BLOCK_SIZE      = 1 << 20
+	FEE_ESTIMATE_BLOCKS = 10
+	TYPICAL_TX_SIZE     = 400
 )
 
+// BLOCK_VERSION is the header format version written into every new block.
+const BLOCK_VERSION = 1
+
 type Block struct {
+	version      int
+	height       uint64
 	timestamp    int64
 	transactions []*Transaction
 	prevHash     string
+	merkleRoot   string
 	hash         string
 	nonce        int
+	difficulty   int
 }
 
-func NewBlock(transactions []*Transaction, prevHash string) *Block {
+func NewBlock(height uint64, transactions []*Transaction, prevHash string, difficulty int) *Block {
 	return &Block{
+		version:      BLOCK_VERSION,
+		height:       height,
 		timestamp:    time.Now().Unix(),
 		transactions: transactions,
 		prevHash:     prevHash,
+		merkleRoot:   MerkleRoot(transactions),
 		hash:         "",
 		nonce:        0,
+		difficulty:   difficulty,
 	}
 }
 
+// CalculateHash hashes every header field except the hash itself. The
+// transactions are committed to through the Merkle root.
 func (b *Block) CalculateHash() string {
 	m, err := json.Marshal(struct {
-		Timestamp    int64          `json:"timestamp"`
-		Transactions []*Transaction `json:"transactions"`
-		PrevHash     string         `json:"prevHash"`
-		Nonce        int            `json:"nonce"`
+		Version    int    `json:"version"`
+		Height     uint64 `json:"height"`
+		Timestamp  int64  `json:"timestamp"`
+		PrevHash   string `json:"prevHash"`
+		MerkleRoot string `json:"merkleRoot"`
+		Nonce      int    `json:"nonce"`
+		Difficulty int    `json:"difficulty"`
 	}{
-		Timestamp:    b.timestamp,
-		Transactions: b.transactions,
-		PrevHash:     b.prevHash,
-		Nonce:        b.nonce,
+		Version:    b.version,
+		Height:     b.height,
+		Timestamp:  b.timestamp,
+		PrevHash:   b.prevHash,
+		MerkleRoot: b.merkleRoot,
+		Nonce:      b.nonce,
+		Difficulty: b.difficulty,
 	})
 	if err != nil {
 		log.Printf("ERROR: Failed to marshal block: %v", err)
@@ -68,8 +107,28 @@ func (b *Block) CalculateHash() string {
 	return fmt.Sprintf("%x", hash)
 }
 
+// IsValidHash reports whether the stored hash meets the block's own difficulty.
 func (b *Block) IsValidHash() bool {
-	return b.hash[:2] == "00"
+	return leadingZeroBits(b.hash) >= b.difficulty
+}
+
+// Mine increments the nonce until the block hash satisfies its difficulty.
+func (b *Block) Mine() {
+	for {
+		b.SetHash(b.CalculateHash())
+		if b.IsValidHash() {
+			return
+		}
+		b.SetNonce(b.GetNonce() + 1)
+	}
+}
+
+func (b *Block) GetVersion() int {
+	return b.version
+}
+
+func (b *Block) GetHeight() uint64 {
+	return b.height
 }
 
 func (b *Block) GetHash() string {
@@ -80,10 +139,28 @@ func (b *Block) GetPrevHash() string {
 	return b.prevHash
 }
 
+func (b *Block) GetMerkleRoot() string {
+	return b.merkleRoot
+}
+
 func (b *Block) GetTransactions() []*Transaction {
 	return b.transactions
 }
 
+// Coinbase returns the transaction paying the block's reward, or nil for the
+// genesis block.
+func (b *Block) Coinbase() *Transaction {
+	if b.height == 0 {
+		return nil
+	}
+	for _, t := range b.transactions {
+		if t.IsCoinbase() {
+			return t
+		}
+	}
+	return nil
+}
+
 func (b *Block) GetTimestamp() int64 {
 	return b.timestamp
 }
@@ -92,6 +169,10 @@ func (b *Block) GetNonce() int {
 	return b.nonce
 }
 
+func (b *Block) GetDifficulty() int {
+	return b.difficulty
+}
+
 func (b *Block) SetHash(hash string) {
 	b.hash = hash
 }
@@ -101,9 +182,13 @@ func (b *Block) SetNonce(nonce int) {
 }
 
 func (b *Block) Print() {
+	fmt.Printf("height          %d\n", b.height)
+	fmt.Printf("hash            %s\n", b.hash)
 	fmt.Printf("timestamp       %d\n", b.timestamp)
 	fmt.Printf("nonce           %d\n", b.nonce)
+	fmt.Printf("difficulty      %d\n", b.difficulty)
 	fmt.Printf("previousHash   %s\n", b.prevHash)
+	fmt.Printf("merkleRoot      %s\n", b.merkleRoot)
 	for _, t := range b.transactions {
 		t.Print()
 	}
@@ -115,32 +200,63 @@ func (b *Block) MarshalJSON() ([]byte, error) {
 		transactions = []*Transaction{}
 	}
 	return json.Marshal(struct {
-		Timestamp    int64          `json:"timestamp"`
-		Transactions []*Transaction `json:"transactions"`
+		Version      int            `json:"version"`
+		Height       uint64         `json:"height"`
+		Hash         string         `json:"hash"`
 		PrevHash     string         `json:"prevHash"`
+		MerkleRoot   string         `json:"merkleRoot"`
+		Timestamp    int64          `json:"timestamp"`
 		Nonce        int            `json:"nonce"`
+		Difficulty   int            `json:"difficulty"`
+		Transactions []*Transaction `json:"transactions"`
 	}{
-		Timestamp:    b.timestamp,
-		Transactions: transactions,
+		Version:      b.version,
+		Height:       b.height,
+		Hash:         b.hash,
 		PrevHash:     b.prevHash,
+		MerkleRoot:   b.merkleRoot,
+		Timestamp:    b.timestamp,
 		Nonce:        b.nonce,
+		Difficulty:   b.difficulty,
+		Transactions: transactions,
 	})
 }
 
+// UnmarshalJSON decodes a block and rejects it if the stored Merkle root or
+// hash does not match the value recomputed from the decoded contents.
 func (b *Block) UnmarshalJSON(data []byte) error {
 	v := &struct {
-		Timestamp    *int64          `json:"timestamp"`
-		Transactions *[]*Transaction `json:"transactions"`
+		Version      *int            `json:"version"`
+		Height       *uint64         `json:"height"`
+		Hash         *string         `json:"hash"`
 		PrevHash     *string         `json:"prevHash"`
+		MerkleRoot   *string         `json:"merkleRoot"`
+		Timestamp    *int64          `json:"timestamp"`
 		Nonce        *int            `json:"nonce"`
+		Difficulty   *int            `json:"difficulty"`
+		Transactions *[]*Transaction `json:"transactions"`
 	}{
-		Timestamp:    &b.timestamp,
-		Transactions: &b.transactions,
+		Version:      &b.version,
+		Height:       &b.height,
+		Hash:         &b.hash,
 		PrevHash:     &b.prevHash,
+		MerkleRoot:   &b.merkleRoot,
+		Timestamp:    &b.timestamp,
 		Nonce:        &b.nonce,
+		Difficulty:   &b.difficulty,
+		Transactions: &b.transactions,
 	}
 	if err := json.Unmarshal(data, &v); err != nil {
 		return err
 	}
+	if b.version != BLOCK_VERSION {
+		return fmt.Errorf("block %d: unsupported version %d", b.height, b.version)
+	}
+	if root := MerkleRoot(b.transactions); b.merkleRoot != root {
+		return fmt.Errorf("block %d: merkle root mismatch: got %q, calculated %q", b.height, b.merkleRoot, root)
+	}
+	if calculated := b.CalculateHash(); b.hash != calculated {
+		return fmt.Errorf("block %d: hash mismatch: got %q, calculated %q", b.height, b.hash, calculated)
+	}
 	return nil
 }
@@ -1,15 +1,17 @@
 package block
 
 import (
+	"block/struct/address"
+	"block/struct/config"
+	"block/struct/mempool"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
-	"os"
-	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 )
 
 // ==============================
@@ -18,49 +20,132 @@ import (
 
 // Blockchain represents the entire blockchain structure.
 type Blockchain struct {
-	transactionPool   []*Transaction
+	pool              *mempool.Pool
 	chain             []*Block
 	blockchainAddress string
-	port              uint16
+	config            *config.Config
+	store             *store
+	genesis           *Genesis
+	genesisHash       string
+	params            *ChainParams
+	forkChoice        ForkChoiceRule
+	state             *chainState
+	reorgs            []*ReorgEvent
 	mux               sync.Mutex
 	neighbors         []string
 	muxNeighbors      sync.Mutex
+	mining            bool
+	miningRun         uint64
+	miningTimer       *time.Timer
+	muxMining         sync.Mutex
 }
 
-// NewBlockchain creates a new instance of Blockchain.
-func NewBlockchain(blockchainAddress string, port uint16) *Blockchain {
-	b := &Block{}
+// NewBlockchain creates a new instance of Blockchain holding only the
+// genesis block of the given network.
+func NewBlockchain(blockchainAddress string, cfg *config.Config, genesis *Genesis) *Blockchain {
 	bc := new(Blockchain)
 	bc.blockchainAddress = blockchainAddress
-	bc.CreateBlock([]*Transaction{}, b.GetHash())
-	bc.port = port
+	bc.initialize(cfg, genesis)
+	bc.resetToGenesis()
 	return bc
 }
 
-// Chain returns the chain of the Blockchain.
+// initialize sets up the parts of a Blockchain that are not persisted.
+func (bc *Blockchain) initialize(cfg *config.Config, genesis *Genesis) {
+	bc.config = cfg
+	bc.genesis = genesis
+	bc.genesisHash = genesis.Hash()
+	bc.params = genesis.Params
+	bc.forkChoice = HeaviestChainRule{}
+	bc.pool = mempool.New(cfg.MempoolConfig())
+}
+
+// resetToGenesis drops every block after the genesis block.
+func (bc *Blockchain) resetToGenesis() {
+	bc.chain = []*Block{bc.genesis.Block()}
+	bc.state = nil
+	if err := bc.refreshState(); err != nil {
+		log.Printf("ERROR: %v", err)
+	}
+}
+
+// Chain returns a snapshot of the chain of the Blockchain.
 func (bc *Blockchain) Chain() []*Block {
-	return bc.chain
+	bc.mux.Lock()
+	defer bc.mux.Unlock()
+	return append([]*Block(nil), bc.chain...)
+}
+
+// SetForkChoice replaces the rule used to pick between competing chains.
+func (bc *Blockchain) SetForkChoice(rule ForkChoiceRule) {
+	bc.forkChoice = rule
+}
+
+// Config returns the node configuration the Blockchain runs with.
+func (bc *Blockchain) Config() *config.Config {
+	return bc.config
+}
+
+// Params returns the consensus parameters of the Blockchain.
+func (bc *Blockchain) Params() *ChainParams {
+	return bc.params
+}
+
+// Genesis returns the definition of the network the Blockchain belongs to.
+func (bc *Blockchain) Genesis() *Genesis {
+	return bc.genesis
+}
+
+// GenesisHash returns the hash of the genesis block.
+func (bc *Blockchain) GenesisHash() string {
+	return bc.genesisHash
+}
+
+// SetMinerAddress sets the address that blocks mined from now on pay.
+func (bc *Blockchain) SetMinerAddress(blockchainAddress string) {
+	bc.mux.Lock()
+	defer bc.mux.Unlock()
+	bc.blockchainAddress = blockchainAddress
 }
 
 // Run initializes and runs the Blockchain.
 func (bc *Blockchain) Run() {
 	bc.StartSyncNeighbors()
 	bc.ResolveConflicts()
-	bc.StartMining() // Start mining automatically
+	if bc.config.Mining {
+		bc.StartMining()
+	}
 }
 
-func (bc *Blockchain) CreateBlock(transactions []*Transaction, previousHash string) *Block {
-	b := NewBlock(transactions, previousHash)
+// CreateBlock mines a block holding the given transactions and appends it to
+// the chain. The block's transactions leave the pool, and pooled
+// transactions it invalidates are dropped. A block that does not apply to
+// the current state is discarded and leaves the chain untouched.
+func (bc *Blockchain) CreateBlock(transactions []*Transaction, previousHash string) (*Block, error) {
+	b := NewBlock(uint64(len(bc.chain)), transactions, previousHash, bc.NextDifficulty())
+	b.Mine()
+	if err := bc.applyToState(b); err != nil {
+		return nil, fmt.Errorf("mined block is invalid: %v", err)
+	}
 	bc.chain = append(bc.chain, b)
-	bc.transactionPool = []*Transaction{}
-	for _, n := range bc.neighbors {
-		endpoint := fmt.Sprintf("http://%s/transactions", n)
-		client := &http.Client{}
-		req, _ := http.NewRequest("DELETE", endpoint, nil)
-		resp, _ := client.Do(req)
-		log.Printf("%v", resp)
+	bc.syncPool(transactions)
+	return b, nil
+}
+
+// syncPool removes confirmed transactions from the pool and drops pooled
+// transactions that no longer fit the current state.
+func (bc *Blockchain) syncPool(confirmed []*Transaction) {
+	ids := make([]string, len(confirmed))
+	for i, t := range confirmed {
+		ids[i] = t.ID()
+	}
+	bc.pool.Remove(ids...)
+	if bc.state == nil {
+		return
+	}
+	for _, t := range bc.pool.Prune(bc.state) {
+		log.Printf("WARNING: Dropped pooled transaction %s: no longer valid", t.ID())
 	}
-	return b
 }
 
 func (bc *Blockchain) LastBlock() *Block {
@@ -114,44 +199,42 @@ func (bc *Blockchain) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-// MineBlock creates a new block with pending transactions and rewards the miner
-func (bc *Blockchain) MineBlock(minerAddress string) (bool, error) {
+// MineBlock creates a new block with pending transactions and rewards the
+// miner. It returns the mined block.
+func (bc *Blockchain) MineBlock(minerAddress string) (*Block, error) {
+	if err := address.Validate(minerAddress); err != nil {
+		return nil, fmt.Errorf("miner: %w", err)
+	}
+
 	// Lock the blockchain while mining
 	bc.mux.Lock()
 	defer bc.mux.Unlock()
 
-	// Get the last block
-	lastBlock := bc.chain[len(bc.chain)-1]
-
-	// Create a new block with current transactions
-	newBlock := NewBlock(bc.transactionPool, lastBlock.GetHash())
+	// Mine a new block with the pooled transactions and the reward
+	return bc.mineBlock(minerAddress)
+}
 
-	// Add mining reward transaction
-	rewardTx := &Transaction{
-		senderBlockchainAddress:    MINING_SENDER,
-		recipientBlockchainAddress: minerAddress,
-		value:                      MINING_REWARD,
-		message:                    "MINING REWARD",
-	}
-	newBlock.transactions = append(newBlock.transactions, rewardTx)
-
-	// Mine the block (find a valid hash)
-	for {
-		hash := newBlock.CalculateHash()
-		newBlock.SetHash(hash)
-		if newBlock.IsValidHash() {
-			break
+// GetBlockByHash returns the block with the given hash, or nil if it is not
+// part of the chain.
+func (bc *Blockchain) GetBlockByHash(hash string) *Block {
+	bc.mux.Lock()
+	defer bc.mux.Unlock()
+	for _, b := range bc.chain {
+		if b.GetHash() == hash {
+			return b
 		}
-		newBlock.SetNonce(newBlock.GetNonce() + 1)
 	}
+	return nil
+}
 
-	// Add the new block to the chain
-	bc.chain = append(bc.chain, newBlock)
-
-	// Clear the transaction pool
-	bc.transactionPool = []*Transaction{}
-
-	return true, nil
+// GetBlockByHeight returns the block at a height, or nil past the tip.
+func (bc *Blockchain) GetBlockByHeight(height uint64) *Block {
+	bc.mux.Lock()
+	defer bc.mux.Unlock()
+	if height >= uint64(len(bc.chain)) {
+		return nil
+	}
+	return bc.chain[height]
 }
 
 // GetWallets returns a list of all registered wallet addresses in the blockchain
@@ -172,7 +255,7 @@ func (bc *Blockchain) GetWallets() []string {
 			log.Printf("  From: %s", tx.senderBlockchainAddress)
 			log.Printf("  To: %s", tx.recipientBlockchainAddress)
 			log.Printf("  Message: %s", tx.message)
-			log.Printf("  Amount: %f", tx.value)
+			log.Printf("  Amount: %s", tx.value)
 
 			// Only include wallets that were registered with "REGISTER USER WALLET" message
 			if tx.message == "REGISTER USER WALLET" &&
@@ -205,11 +288,11 @@ func (bc *Blockchain) GetWallets() []string {
 	return wallets
 }
 
-// GetNeighbors returns the list of connected nodes
+// GetNeighbors returns a copy of the list of connected nodes
 func (bc *Blockchain) GetNeighbors() []string {
 	bc.muxNeighbors.Lock()
 	defer bc.muxNeighbors.Unlock()
-	return bc.neighbors
+	return append([]string(nil), bc.neighbors...)
 }
 
 // Reset resets the blockchain to its initial state
@@ -220,26 +303,16 @@ func (bc *Blockchain) Reset() {
 
 	log.Printf("Starting blockchain reset...")
 
-	// Clear the chain and transaction pool
-	bc.chain = []*Block{}
-	bc.transactionPool = []*Transaction{}
-
-	// Create a new genesis block
-	b := &Block{}
-	bc.CreateBlock([]*Transaction{}, b.GetHash())
+	// Clear the transaction pool and go back to the genesis block
+	bc.pool.Clear()
+	bc.resetToGenesis()
 
 	// Clear neighbors
 	bc.muxNeighbors.Lock()
 	bc.neighbors = []string{}
 	bc.muxNeighbors.Unlock()
 
-	// Delete the blockchain file
-	filePath := filepath.Join("data", blockchainFile)
-	if err := os.Remove(filePath); err != nil {
-		log.Printf("Warning: Could not delete blockchain file: %v", err)
-	}
-
-	// Save the reset blockchain
+	// Save the reset blockchain, replacing the stored one
 	if err := bc.SaveBlockchain(); err != nil {
 		log.Printf("ERROR: Failed to save reset blockchain: %v", err)
 	} else {
@@ -247,17 +320,21 @@ func (bc *Blockchain) Reset() {
 	}
 }
 
+// ResolveConflicts fetches every neighbor's chain and adopts the best valid
+// one according to the fork-choice rule, returning orphaned transactions to
+// the pool.
 func (bc *Blockchain) ResolveConflicts() bool {
-	// Initialize variables to track the longest chain and its length
-	var longestChain []*Block = nil
-	maxLength := len(bc.chain)
+	// Track the best chain seen so far, starting from our own
+	var bestChain []*Block = nil
+	best := bc.Chain()
 
-	for _, n := range bc.neighbors {
-		fmt.Println("Resolve conflict with ", n)
+	client := &http.Client{Timeout: CONSENSUS_FETCH_TIMEOUT_SEC * time.Second}
+	for _, n := range bc.GetNeighbors() {
+		log.Printf("INFO: Resolving conflicts with neighbor %s", n)
 
 		endpoint := fmt.Sprintf("%s/chain", n)
 
-		resp, err := http.Get(endpoint)
+		resp, err := client.Get(endpoint)
 		if err != nil {
 			log.Printf("ERROR: Failed to fetch chain from neighbor %s: %v", n, err)
 			continue // Skip to the next neighbor in case of error
@@ -268,34 +345,52 @@ func (bc *Blockchain) ResolveConflicts() bool {
 			decoder := json.NewDecoder(resp.Body)
 
 			err := decoder.Decode(&bcResp)
+			resp.Body.Close()
 			if err != nil {
 				log.Printf("ERROR: Failed to decode JSON response from neighbor %s: %v", n, err)
 				continue // Skip to the next neighbor in case of error
 			}
 
 			chain := bcResp.Chain()
+			if len(chain) == 0 || chain[0].GetHash() != bc.genesisHash {
+				log.Printf("WARNING: Ignoring chain of neighbor %s: it does not start from our genesis block %s", n, bc.genesisHash)
+				continue
+			}
 
-			if len(chain) > maxLength && bc.ValidChain(chain) {
-				maxLength = len(chain)
-				longestChain = chain
+			if bc.forkChoice.Prefer(best, chain) && bc.ValidChain(chain) {
+				best = chain
+				bestChain = chain
 			}
 		} else {
+			resp.Body.Close()
 			log.Printf("WARNING: Failed to fetch chain from neighbor %s. Status code: %d", n, resp.StatusCode)
 		}
 	}
 
-	if longestChain != nil {
-		bc.chain = longestChain
-		log.Printf("INFO: Resolved conflicts. Replaced blockchain with the longest valid chain.")
+	if bestChain != nil {
+		bc.mux.Lock()
+		// Our chain may have grown while the neighbors were queried
+		if !bc.forkChoice.Prefer(bc.chain, bestChain) {
+			bc.mux.Unlock()
+			log.Printf("INFO: Local chain overtook the neighbors' chains. No conflicts resolved.")
+			return false
+		}
+		if _, err := bc.replaceChain(bestChain); err != nil {
+			bc.mux.Unlock()
+			log.Printf("ERROR: Failed to adopt the neighbors' chain: %v", err)
+			return false
+		}
 
 		// Save blockchain after resolving conflicts
 		if err := bc.SaveBlockchain(); err != nil {
 			log.Printf("ERROR: Failed to save blockchain after resolving conflicts: %v", err)
 		}
+		bc.mux.Unlock()
+		log.Printf("INFO: Resolved conflicts. Replaced blockchain with the heaviest valid chain (work %s).", CumulativeWork(bestChain))
 
 		return true
 	}
 
-	log.Printf("INFO: No longer valid chain found among neighbors. No conflicts resolved.")
+	log.Printf("INFO: No heavier valid chain found among neighbors. No conflicts resolved.")
 	return false
 }
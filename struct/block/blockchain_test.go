@@ -0,0 +1,166 @@
+package block
+
+import (
+	"block/struct/address"
+	"block/struct/amount"
+	"block/struct/config"
+	"block/struct/signing"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// testGenesis returns a network with a difficulty low enough to mine blocks
+// at once, funding every address with 10 coins.
+func testGenesis(funded ...string) *Genesis {
+	g := DefaultGenesis()
+	g.Params.InitialDifficulty, g.Params.MinDifficulty, g.Params.MaxDifficulty = 4, 4, 6
+	for _, a := range funded {
+		g.Allocations = append(g.Allocations, Allocation{Address: a, Amount: amount.MustFromCoins(10)})
+	}
+	return g
+}
+
+func newTestKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	t.Helper()
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return privateKey, address.FromPublicKey(&privateKey.PublicKey)
+}
+
+func newTestAddress(t *testing.T) string {
+	t.Helper()
+	_, a := newTestKey(t)
+	return a
+}
+
+// signedTransfer builds a transfer signed by privateKey for chainID.
+func signedTransfer(t *testing.T, privateKey *ecdsa.PrivateKey, chainID string, recipient string,
+	value amount.Amount, fee amount.Amount, nonce uint64) *Transaction {
+	t.Helper()
+	tx := &Transaction{
+		chainID:                    chainID,
+		senderBlockchainAddress:    address.FromPublicKey(&privateKey.PublicKey),
+		recipientBlockchainAddress: recipient,
+		value:                      value,
+		fee:                        fee,
+		nonce:                      nonce,
+		senderPublicKey:            &privateKey.PublicKey,
+	}
+	s, err := signing.Sign(privateKey, tx.SigningPayload())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.signature = s
+	tx.seal()
+	return tx
+}
+
+// submit adds tx to the pool of bc.
+func submit(t *testing.T, bc *Blockchain, tx *Transaction) {
+	t.Helper()
+	if _, err := bc.AddTransaction(tx.chainID, tx.senderBlockchainAddress, tx.recipientBlockchainAddress,
+		tx.message, tx.value, tx.fee, tx.nonce, tx.senderPublicKey, tx.signature); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// mine mines n blocks paying miner.
+func mine(t *testing.T, bc *Blockchain, miner string, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if _, err := bc.MineBlock(miner); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func newTestBlockchain(t *testing.T, g *Genesis) *Blockchain {
+	t.Helper()
+	return NewBlockchain(newTestAddress(t), config.Default(), g)
+}
+
+func TestGetBlockWhileMining(t *testing.T) {
+	bc := newTestBlockchain(t, testGenesis())
+	genesis := bc.GenesisHash()
+	miner := newTestAddress(t)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			if _, err := bc.MineBlock(miner); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			if b := bc.GetBlockByHeight(20); b == nil || bc.GetBlockByHash(b.GetHash()) != b {
+				t.Fatal("mined tip is not found by its hash")
+			}
+			return
+		default:
+		}
+		if bc.GetBlockByHash(genesis) == nil {
+			t.Fatal("genesis block is not found by its hash")
+		}
+	}
+}
+
+// serveChain serves the chain of bc on /chain, like a neighbor.
+func serveChain(t *testing.T, bc *Blockchain) string {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/chain" {
+			http.NotFound(w, req)
+			return
+		}
+		json.NewEncoder(w).Encode(&Blockchain{chain: bc.Chain()})
+	}))
+	t.Cleanup(ts.Close)
+	return ts.URL
+}
+
+func TestResolveConflicts(t *testing.T) {
+	g := testGenesis()
+	local := newTestBlockchain(t, g)
+	remote := newTestBlockchain(t, g)
+	mine(t, local, newTestAddress(t), 1)
+	mine(t, remote, newTestAddress(t), 3)
+
+	// An unreachable neighbor does not keep the others from being asked
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+	local.neighbors = []string{unreachable.URL, serveChain(t, remote)}
+
+	if !local.ResolveConflicts() {
+		t.Fatal("ResolveConflicts kept the lighter local chain")
+	}
+	if local.LastBlock().GetHash() != remote.LastBlock().GetHash() {
+		t.Fatalf("local tip %s, want the neighbor's tip %s", local.LastBlock().GetHash(), remote.LastBlock().GetHash())
+	}
+	if local.ResolveConflicts() {
+		t.Fatal("ResolveConflicts replaced a chain as heavy as the neighbor's")
+	}
+}
+
+// coinbase pays miner the reward of the block at height.
+func coinbase(miner string, reward amount.Amount, height uint64) *Transaction {
+	return NewTransaction(MINING_SENDER, miner, "MINING REWARD", reward, 0, height)
+}
+
+// nextBlock mines a block of the given transactions on top of chain.
+func nextBlock(chain []*Block, difficulty int, transactions ...*Transaction) *Block {
+	tip := chain[len(chain)-1]
+	b := NewBlock(tip.GetHeight()+1, transactions, tip.GetHash(), difficulty)
+	b.Mine()
+	return b
+}
@@ -0,0 +1,69 @@
+package block
+
+import (
+	"encoding/hex"
+	"math/bits"
+)
+
+// leadingZeroBits counts the zero bits at the start of a hex encoded hash.
+func leadingZeroBits(hash string) int {
+	b, err := hex.DecodeString(hash)
+	if err != nil || len(b) == 0 {
+		return 0
+	}
+	n := 0
+	for _, v := range b {
+		if v != 0 {
+			return n + bits.LeadingZeros8(v)
+		}
+		n += 8
+	}
+	return n
+}
+
+// ExpectedDifficulty returns the difficulty the block at the given height
+// must carry, computed from the blocks before it. The difficulty only moves
+// every RetargetInterval blocks, by one bit for each doubling between the
+// observed and the targeted time span of the previous interval.
+func (p *ChainParams) ExpectedDifficulty(chain []*Block, height uint64) int {
+	if height == 0 || height > uint64(len(chain)) {
+		return p.InitialDifficulty
+	}
+
+	parent := chain[height-1]
+	if p.RetargetInterval < 2 || height%p.RetargetInterval != 0 {
+		return parent.GetDifficulty()
+	}
+
+	first := chain[height-p.RetargetInterval]
+	actual := parent.GetTimestamp() - first.GetTimestamp()
+	if actual < 1 {
+		actual = 1
+	}
+	expected := p.TargetBlockTimeSec * int64(p.RetargetInterval-1)
+
+	step := 0
+	for step < p.MaxRetargetStep && actual*2 <= expected {
+		actual *= 2
+		step++
+	}
+	for step > -p.MaxRetargetStep && actual >= expected*2 {
+		expected *= 2
+		step--
+	}
+
+	difficulty := parent.GetDifficulty() + step
+	if difficulty < p.MinDifficulty {
+		difficulty = p.MinDifficulty
+	}
+	if difficulty > p.MaxDifficulty {
+		difficulty = p.MaxDifficulty
+	}
+	return difficulty
+}
+
+// NextDifficulty returns the difficulty required for the next block mined on
+// top of the current chain.
+func (bc *Blockchain) NextDifficulty() int {
+	return bc.params.ExpectedDifficulty(bc.chain, uint64(len(bc.chain)))
+}
@@ -0,0 +1,106 @@
+package block
+
+import "testing"
+
+// timedChain returns n blocks spaced by spacing seconds, all of the given
+// difficulty.
+func timedChain(n int, spacing int64, difficulty int) []*Block {
+	chain := make([]*Block, n)
+	for i := range chain {
+		chain[i] = &Block{height: uint64(i), timestamp: 1700000000 + int64(i)*spacing, difficulty: difficulty}
+	}
+	return chain
+}
+
+func TestExpectedDifficulty(t *testing.T) {
+	p := &ChainParams{
+		InitialDifficulty:  12,
+		MinDifficulty:      4,
+		MaxDifficulty:      32,
+		TargetBlockTimeSec: 20,
+		RetargetInterval:   10,
+		MaxRetargetStep:    2,
+	}
+	tests := []struct {
+		name       string
+		blocks     int
+		spacing    int64
+		difficulty int
+		height     uint64
+		want       int
+	}{
+		{"genesis", 10, 20, 16, 0, 12},
+		{"past the tip", 10, 20, 16, 11, 12},
+		{"between retargets", 11, 1, 16, 11, 16},
+		{"on target", 10, 20, 16, 10, 16},
+		{"twice as fast", 10, 10, 16, 10, 17},
+		{"four times as fast", 10, 5, 16, 10, 18},
+		{"step capped going up", 10, 1, 16, 10, 18},
+		{"same timestamps", 10, 0, 16, 10, 18},
+		{"timestamps going back", 10, -5, 16, 10, 18},
+		{"twice as slow", 10, 40, 16, 10, 15},
+		{"step capped going down", 10, 1000, 16, 10, 14},
+		{"slightly fast", 10, 15, 16, 10, 16},
+		{"clamped to the maximum", 10, 1, 31, 10, 32},
+		{"clamped to the minimum", 10, 1000, 5, 10, 4},
+	}
+	for _, tt := range tests {
+		chain := timedChain(tt.blocks, tt.spacing, tt.difficulty)
+		if got := p.ExpectedDifficulty(chain, tt.height); got != tt.want {
+			t.Errorf("%s: ExpectedDifficulty at %d = %d, want %d", tt.name, tt.height, got, tt.want)
+		}
+	}
+
+	// Without an interval of at least two blocks the difficulty never moves
+	p.RetargetInterval = 1
+	if got := p.ExpectedDifficulty(timedChain(10, 1, 16), 10); got != 16 {
+		t.Errorf("ExpectedDifficulty without retargeting = %d, want 16", got)
+	}
+}
+
+func TestLeadingZeroBits(t *testing.T) {
+	tests := map[string]int{
+		"ff":     0,
+		"7f":     1,
+		"0f":     4,
+		"0001":   15,
+		"000080": 16,
+		"0000":   16,
+		"":       0,
+		"zz":     0,
+	}
+	for hash, want := range tests {
+		if got := leadingZeroBits(hash); got != want {
+			t.Errorf("leadingZeroBits(%q) = %d, want %d", hash, got, want)
+		}
+	}
+}
+
+func TestVerifyChainChecksDifficulty(t *testing.T) {
+	bc := newTestBlockchain(t, testGenesis())
+	mine(t, bc, newTestAddress(t), 2)
+	chain := bc.Chain()
+	height := uint64(len(chain))
+	reward := bc.params.BlockSubsidy(height, bc.state.Supply())
+	expected := bc.NextDifficulty()
+
+	valid := nextBlock(chain, expected, coinbase(newTestAddress(t), reward, height))
+	if err := bc.VerifyChain(append(chain, valid)); err != nil {
+		t.Fatalf("block at the expected difficulty is rejected: %v", err)
+	}
+	for _, difficulty := range []int{expected - 1, expected + 1} {
+		b := nextBlock(chain, difficulty, coinbase(newTestAddress(t), reward, height))
+		if err := bc.VerifyChain(append(chain, b)); err == nil {
+			t.Errorf("block at difficulty %d is accepted, expected %d", difficulty, expected)
+		}
+	}
+
+	// A block whose hash misses the difficulty it rightly claims
+	b := NewBlock(height, []*Transaction{coinbase(newTestAddress(t), reward, height)}, chain[len(chain)-1].GetHash(), expected)
+	for b.SetHash(b.CalculateHash()); b.IsValidHash(); b.SetHash(b.CalculateHash()) {
+		b.SetNonce(b.GetNonce() + 1)
+	}
+	if err := bc.VerifyChain(append(chain, b)); err == nil {
+		t.Error("block whose hash misses its difficulty is accepted")
+	}
+}
@@ -0,0 +1,65 @@
+package block
+
+import (
+	"block/struct/amount"
+	"sort"
+)
+
+// FeeEstimate suggests a fee rate, in base units per byte, from the fees
+// paid in recent blocks and the competition in the transaction pool.
+type FeeEstimate struct {
+	SampledBlocks  int           `json:"sampledBlocks"`
+	SampledTxs     int           `json:"sampledTransactions"`
+	MedianFeeRate  uint64        `json:"medianFeeRate"`
+	PoolCount      int           `json:"poolCount"`
+	PoolSize       int           `json:"poolSize"`
+	PoolMinFeeRate uint64        `json:"poolMinFeeRate"`
+	FeeRate        uint64        `json:"feeRate"`
+	TypicalTxSize  int           `json:"typicalTransactionSize"`
+	RecommendedFee amount.Amount `json:"recommendedFee"`
+}
+
+// EstimateFee takes the median fee rate of the transfers in the last
+// FEE_ESTIMATE_BLOCKS blocks. If the pool holds more than one block can take,
+// the rate needed to outbid the best transaction left out is used when higher.
+func (bc *Blockchain) EstimateFee() *FeeEstimate {
+	bc.mux.Lock()
+	defer bc.mux.Unlock()
+
+	estimate := &FeeEstimate{TypicalTxSize: TYPICAL_TX_SIZE}
+
+	var rates []uint64
+	for i := len(bc.chain) - 1; i > 0 && estimate.SampledBlocks < FEE_ESTIMATE_BLOCKS; i-- {
+		for _, t := range bc.chain[i].transactions {
+			if t.senderBlockchainAddress != MINING_SENDER {
+				rates = append(rates, t.FeeRate())
+			}
+		}
+		estimate.SampledBlocks++
+	}
+	estimate.SampledTxs = len(rates)
+	if len(rates) > 0 {
+		sort.Slice(rates, func(i, j int) bool { return rates[i] < rates[j] })
+		estimate.MedianFeeRate = rates[len(rates)/2]
+	}
+
+	pool := bc.TransactionPool()
+	sort.SliceStable(pool, func(i, j int) bool { return higherFeeRate(pool[i], pool[j]) })
+	capacity := bc.params.MaxBlockSize - COINBASE_RESERVED_SIZE
+	for _, t := range pool {
+		estimate.PoolSize += t.Size()
+		if estimate.PoolSize > capacity && estimate.PoolMinFeeRate == 0 {
+			estimate.PoolMinFeeRate = t.FeeRate() + 1
+		}
+	}
+	estimate.PoolCount = len(pool)
+
+	estimate.FeeRate = estimate.MedianFeeRate
+	if estimate.PoolMinFeeRate > estimate.FeeRate {
+		estimate.FeeRate = estimate.PoolMinFeeRate
+	}
+	if fee, err := amount.Amount(estimate.FeeRate).MulInt(TYPICAL_TX_SIZE); err == nil {
+		estimate.RecommendedFee = fee
+	}
+	return estimate
+}
@@ -0,0 +1,37 @@
+package block
+
+import (
+	"math/big"
+)
+
+// ForkChoiceRule decides which of two valid chains a node should follow.
+type ForkChoiceRule interface {
+	// Prefer reports whether candidate should replace current.
+	Prefer(current []*Block, candidate []*Block) bool
+}
+
+// BlockWork returns the expected number of hashes needed to mine a block of
+// the given difficulty, 2^difficulty.
+func BlockWork(difficulty int) *big.Int {
+	if difficulty < 0 {
+		difficulty = 0
+	}
+	return new(big.Int).Lsh(big.NewInt(1), uint(difficulty))
+}
+
+// CumulativeWork sums the work of every block in the chain.
+func CumulativeWork(chain []*Block) *big.Int {
+	total := new(big.Int)
+	for _, b := range chain {
+		total.Add(total, BlockWork(b.GetDifficulty()))
+	}
+	return total
+}
+
+// HeaviestChainRule prefers the chain with the most cumulative proof-of-work.
+// Ties keep the current chain so a node does not flip between equal forks.
+type HeaviestChainRule struct{}
+
+func (HeaviestChainRule) Prefer(current []*Block, candidate []*Block) bool {
+	return CumulativeWork(candidate).Cmp(CumulativeWork(current)) > 0
+}
@@ -0,0 +1,47 @@
+package block
+
+import "testing"
+
+// difficultyChain returns a chain with one block per given difficulty.
+func difficultyChain(difficulties ...int) []*Block {
+	chain := make([]*Block, len(difficulties))
+	for i, d := range difficulties {
+		chain[i] = &Block{height: uint64(i), difficulty: d}
+	}
+	return chain
+}
+
+func TestBlockWork(t *testing.T) {
+	tests := map[int]string{-1: "1", 0: "1", 1: "2", 12: "4096", 64: "18446744073709551616"}
+	for difficulty, want := range tests {
+		if got := BlockWork(difficulty).String(); got != want {
+			t.Errorf("BlockWork(%d) = %s, want %s", difficulty, got, want)
+		}
+	}
+	if got := CumulativeWork(difficultyChain(4, 4, 5)).String(); got != "64" {
+		t.Errorf("CumulativeWork = %s, want 64", got)
+	}
+}
+
+func TestHeaviestChainRule(t *testing.T) {
+	tests := []struct {
+		name      string
+		current   []int
+		candidate []int
+		want      bool
+	}{
+		{"longer at the same difficulty", []int{4, 4}, []int{4, 4, 4}, true},
+		{"shorter at the same difficulty", []int{4, 4, 4}, []int{4, 4}, false},
+		{"shorter but heavier", []int{4, 4, 4, 4, 4}, []int{4, 8}, true},
+		{"longer but lighter", []int{4, 8}, []int{4, 4, 4, 4, 4}, false},
+		{"equal work", []int{4, 5}, []int{4, 4, 4}, false},
+		{"identical", []int{4, 4}, []int{4, 4}, false},
+		{"empty current", nil, []int{4}, true},
+	}
+	rule := HeaviestChainRule{}
+	for _, tt := range tests {
+		if got := rule.Prefer(difficultyChain(tt.current...), difficultyChain(tt.candidate...)); got != tt.want {
+			t.Errorf("%s: Prefer = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
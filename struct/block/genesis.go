@@ -0,0 +1,145 @@
+package block
+
+import (
+	"block/struct/address"
+	"block/struct/amount"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Genesis related constants. The default genesis is what nodes use when no
+// genesis file is present; its fixed timestamp lets them agree on one chain.
+const (
+	GENESIS_FILE      = "genesis.json"
+	GENESIS_CHAIN_ID  = "go-blockchain-devnet"
+	GENESIS_TIMESTAMP = 1735689600 // 2025-01-01T00:00:00Z
+	GENESIS_MESSAGE   = "GENESIS ALLOCATION"
+
+	GENESIS_CHECK_TIMEOUT_SEC = 5
+)
+
+// Allocation premines an amount to an address in the genesis block.
+type Allocation struct {
+	Address string        `json:"address"`
+	Amount  amount.Amount `json:"amount"`
+}
+
+// Genesis defines a network: its chain ID, the consensus parameters every
+// node must share and the contents of the genesis block.
+type Genesis struct {
+	ChainID     string       `json:"chainId"`
+	Timestamp   int64        `json:"timestamp"`
+	Params      *ChainParams `json:"params"`
+	Allocations []Allocation `json:"allocations"`
+}
+
+// GenesisResponse is how a node advertises the network it belongs to.
+type GenesisResponse struct {
+	ChainID string   `json:"chainId"`
+	Hash    string   `json:"hash"`
+	Genesis *Genesis `json:"genesis"`
+}
+
+// DefaultGenesis returns the genesis of the default development network.
+func DefaultGenesis() *Genesis {
+	return &Genesis{
+		ChainID:     GENESIS_CHAIN_ID,
+		Timestamp:   GENESIS_TIMESTAMP,
+		Params:      DefaultChainParams(),
+		Allocations: []Allocation{},
+	}
+}
+
+// LoadGenesis reads and validates a genesis file. Consensus parameters the
+// file leaves out keep their defaults.
+func LoadGenesis(path string) (*Genesis, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Genesis{Params: DefaultChainParams()}
+	if err := json.Unmarshal(data, g); err != nil {
+		return nil, fmt.Errorf("failed to parse genesis file %s: %v", path, err)
+	}
+	if err := g.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid genesis file %s: %v", path, err)
+	}
+	return g, nil
+}
+
+// Save writes the genesis to path.
+func (g *Genesis) Save(path string) error {
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal genesis: %v", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write genesis file: %v", err)
+	}
+	return nil
+}
+
+// Validate checks the chain ID, the consensus parameters and that the
+// allocations fit the maximum supply.
+func (g *Genesis) Validate() error {
+	if g.ChainID == "" {
+		return fmt.Errorf("chain ID is required")
+	}
+	if g.Params == nil {
+		return fmt.Errorf("consensus parameters are required")
+	}
+	if err := g.Params.Validate(); err != nil {
+		return err
+	}
+
+	var total amount.Amount
+	var err error
+	for i, a := range g.Allocations {
+		if err := address.Validate(a.Address); err != nil {
+			return fmt.Errorf("allocation %d: %w", i, err)
+		}
+		if a.Amount == 0 {
+			return fmt.Errorf("allocation %d to %s is zero", i, a.Address)
+		}
+		if total, err = total.Add(a.Amount); err != nil {
+			return fmt.Errorf("allocations: %v", err)
+		}
+	}
+	if total > g.Params.MaxSupply {
+		return fmt.Errorf("allocations of %s exceed the maximum supply of %s", total, g.Params.MaxSupply)
+	}
+	return nil
+}
+
+// ConfigHash hashes the genesis definition. The genesis block links to it in
+// place of a previous block, so the block hash commits to the chain ID and
+// the consensus parameters.
+func (g *Genesis) ConfigHash() string {
+	m, err := json.Marshal(g)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(m))
+}
+
+// Block builds the genesis block: one transaction per allocation, at the
+// genesis timestamp and initial difficulty. It is hashed but not mined.
+func (g *Genesis) Block() *Block {
+	transactions := make([]*Transaction, len(g.Allocations))
+	for i, a := range g.Allocations {
+		transactions[i] = NewTransaction(MINING_SENDER, a.Address, GENESIS_MESSAGE, a.Amount, 0, uint64(i))
+	}
+
+	b := NewBlock(0, transactions, g.ConfigHash(), g.Params.InitialDifficulty)
+	b.timestamp = g.Timestamp
+	b.SetHash(b.CalculateHash())
+	return b
+}
+
+// Hash returns the hash of the genesis block.
+func (g *Genesis) Hash() string {
+	return g.Block().GetHash()
+}
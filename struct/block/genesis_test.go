@@ -0,0 +1,135 @@
+package block
+
+import (
+	"block/struct/amount"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeGenesis(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), GENESIS_FILE)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadGenesis(t *testing.T) {
+	a, b := newTestAddress(t), newTestAddress(t)
+	path := writeGenesis(t, `{
+		"chainId": "testnet",
+		"timestamp": 1700000000,
+		"params": {"initialDifficulty": 6, "coinbaseMaturity": 3},
+		"allocations": [
+			{"address": "`+a+`", "amount": "1"},
+			{"address": "`+b+`", "amount": "0.0000025"}
+		]
+	}`)
+	g, err := LoadGenesis(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.ChainID != "testnet" || g.Timestamp != 1700000000 || len(g.Allocations) != 2 {
+		t.Fatalf("LoadGenesis = %+v", g)
+	}
+	// Parameters the file leaves out keep their defaults
+	defaults := DefaultChainParams()
+	if g.Params.InitialDifficulty != 6 || g.Params.CoinbaseMaturity != 3 ||
+		g.Params.MaxSupply != defaults.MaxSupply || g.Params.TargetBlockTimeSec != defaults.TargetBlockTimeSec {
+		t.Fatalf("LoadGenesis params = %+v", g.Params)
+	}
+
+	// The genesis block pays the allocations, and they are spendable
+	block := g.Block()
+	if block.GetHeight() != 0 || block.GetTimestamp() != g.Timestamp || block.GetDifficulty() != 6 ||
+		block.GetPrevHash() != g.ConfigHash() || len(block.GetTransactions()) != 2 {
+		t.Fatalf("genesis block does not follow the file")
+	}
+	state, err := replayChain([]*Block{block}, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Balance(a) != amount.MustFromCoins(1) || state.Balance(b) != 250 || state.Supply() != amount.MustFromCoins(1)+250 {
+		t.Fatalf("allocations give %s and %s with supply %s", state.Balance(a), state.Balance(b), state.Supply())
+	}
+
+	// Save and load give the same network
+	saved := filepath.Join(t.TempDir(), GENESIS_FILE)
+	if err := g.Save(saved); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := LoadGenesis(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.Hash() != g.Hash() {
+		t.Fatalf("reloaded genesis hash %s, want %s", reloaded.Hash(), g.Hash())
+	}
+}
+
+func TestLoadGenesisRejects(t *testing.T) {
+	a := newTestAddress(t)
+	tests := map[string]string{
+		"no chain ID":          `{"allocations": []}`,
+		"malformed JSON":       `{"chainId": "testnet"`,
+		"bad address":          `{"chainId": "testnet", "allocations": [{"address": "nope", "amount": 1}]}`,
+		"zero allocation":      `{"chainId": "testnet", "allocations": [{"address": "` + a + `", "amount": "0"}]}`,
+		"beyond max supply":    `{"chainId": "testnet", "params": {"maxSupply": "10"}, "allocations": [{"address": "` + a + `", "amount": "11"}]}`,
+		"difficulty bounds":    `{"chainId": "testnet", "params": {"minDifficulty": 10, "maxDifficulty": 8}}`,
+		"initial out of range": `{"chainId": "testnet", "params": {"initialDifficulty": 40}}`,
+		"no block time":        `{"chainId": "testnet", "params": {"targetBlockTimeSec": 0}}`,
+	}
+	for name, content := range tests {
+		if _, err := LoadGenesis(writeGenesis(t, content)); err == nil {
+			t.Errorf("%s: LoadGenesis accepted %s", name, content)
+		}
+	}
+	if _, err := LoadGenesis(filepath.Join(t.TempDir(), "missing.json")); !os.IsNotExist(err) {
+		t.Errorf("LoadGenesis of a missing file error = %v, want a not-exist error", err)
+	}
+}
+
+func TestGenesisHashCommitsToNetwork(t *testing.T) {
+	base := DefaultGenesis()
+	hash := base.Hash()
+	if hash != DefaultGenesis().Hash() {
+		t.Fatal("the default genesis hash is not stable")
+	}
+
+	variants := map[string]func(g *Genesis){
+		"chain ID":   func(g *Genesis) { g.ChainID = "other" },
+		"timestamp":  func(g *Genesis) { g.Timestamp++ },
+		"params":     func(g *Genesis) { g.Params.CoinbaseMaturity++ },
+		"allocation": func(g *Genesis) { g.Allocations = []Allocation{{Address: newTestAddress(t), Amount: 1}} },
+	}
+	for name, change := range variants {
+		g := DefaultGenesis()
+		change(g)
+		if g.Hash() == hash {
+			t.Errorf("changing the %s keeps the genesis hash", name)
+		}
+	}
+}
+
+func TestChainIDSeparatesNetworks(t *testing.T) {
+	key, sender := newTestKey(t)
+	g := testGenesis(sender)
+	other := testGenesis(sender)
+	other.ChainID = "other-network"
+	bc := newTestBlockchain(t, g)
+
+	// A chain of another network is refused from its genesis block on
+	if err := bc.VerifyChain([]*Block{other.Block()}); err == nil || !strings.Contains(err.Error(), "genesis") {
+		t.Fatalf("VerifyChain of another network's chain error = %v, want a genesis mismatch", err)
+	}
+
+	// So are transfers signed for it
+	tx := signedTransfer(t, key, other.ChainID, newTestAddress(t), 1000, 0, 0)
+	if _, err := bc.AddTransaction(tx.chainID, sender, tx.recipientBlockchainAddress, "", 1000, 0, 0, &key.PublicKey, tx.signature); err == nil {
+		t.Fatal("AddTransaction accepted a transfer signed for another chain")
+	}
+	submit(t, bc, signedTransfer(t, key, g.ChainID, newTestAddress(t), 1000, 0, 0))
+}
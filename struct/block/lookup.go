@@ -0,0 +1,89 @@
+package block
+
+// Transaction lookup statuses.
+const (
+	TX_STATUS_PENDING   = "pending"
+	TX_STATUS_CONFIRMED = "confirmed"
+	TX_STATUS_UNKNOWN   = "unknown"
+)
+
+// TransactionLookup locates a transaction in the pool or the chain.
+type TransactionLookup struct {
+	ID            string       `json:"id"`
+	Status        string       `json:"status"`
+	Transaction   *Transaction `json:"transaction,omitempty"`
+	BlockHash     string       `json:"blockHash,omitempty"`
+	BlockHeight   *uint64      `json:"blockHeight,omitempty"`
+	Index         *int         `json:"index,omitempty"`
+	Confirmations uint64       `json:"confirmations"`
+}
+
+// FindTransaction looks a transaction up by ID, newest blocks first, then in
+// the transaction pool.
+func (bc *Blockchain) FindTransaction(id string) *TransactionLookup {
+	bc.mux.Lock()
+	defer bc.mux.Unlock()
+
+	tip := bc.LastBlock().GetHeight()
+	for i := len(bc.chain) - 1; i >= 0; i-- {
+		b := bc.chain[i]
+		for index, t := range b.transactions {
+			if t.ID() != id {
+				continue
+			}
+			height := b.GetHeight()
+			index := index
+			return &TransactionLookup{
+				ID:            id,
+				Status:        TX_STATUS_CONFIRMED,
+				Transaction:   t,
+				BlockHash:     b.GetHash(),
+				BlockHeight:   &height,
+				Index:         &index,
+				Confirmations: tip - height + 1,
+			}
+		}
+	}
+
+	if t := bc.pool.Get(id); t != nil {
+		return &TransactionLookup{ID: id, Status: TX_STATUS_PENDING, Transaction: t.(*Transaction)}
+	}
+
+	return &TransactionLookup{ID: id, Status: TX_STATUS_UNKNOWN}
+}
+
+// History returns the transactions that an address sent or received, pending
+// ones first, then confirmed ones from the newest block down.
+func (bc *Blockchain) History(blockchainAddress string) []*TransactionLookup {
+	bc.mux.Lock()
+	defer bc.mux.Unlock()
+
+	history := []*TransactionLookup{}
+	for _, t := range bc.TransactionPool() {
+		if t.senderBlockchainAddress == blockchainAddress || t.recipientBlockchainAddress == blockchainAddress {
+			history = append(history, &TransactionLookup{ID: t.ID(), Status: TX_STATUS_PENDING, Transaction: t})
+		}
+	}
+
+	tip := bc.LastBlock().GetHeight()
+	for i := len(bc.chain) - 1; i >= 0; i-- {
+		b := bc.chain[i]
+		for index, t := range b.transactions {
+			if t.senderBlockchainAddress != blockchainAddress && t.recipientBlockchainAddress != blockchainAddress {
+				continue
+			}
+			height := b.GetHeight()
+			index := index
+			history = append(history, &TransactionLookup{
+				ID:            t.ID(),
+				Status:        TX_STATUS_CONFIRMED,
+				Transaction:   t,
+				BlockHash:     b.GetHash(),
+				BlockHeight:   &height,
+				Index:         &index,
+				Confirmations: tip - height + 1,
+			})
+		}
+	}
+	return history
+}
@@ -0,0 +1,173 @@
+package block
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// Positions of a sibling hash relative to the running hash in a Merkle branch.
+const (
+	MERKLE_LEFT  = "left"
+	MERKLE_RIGHT = "right"
+)
+
+// Domain tags prefixed to what is hashed, so a leaf can never be passed off
+// as an interior node or the other way round.
+const (
+	MERKLE_LEAF_TAG     = 0x00
+	MERKLE_INTERIOR_TAG = 0x01
+)
+
+// MerkleNode is one sibling hash on the path from a leaf to the Merkle root.
+type MerkleNode struct {
+	Hash     string `json:"hash"`
+	Position string `json:"position"`
+}
+
+// MerkleProof proves that a transaction is included in a block. Index and
+// Count fix the shape of the path, so a proof only verifies for the
+// position it claims.
+type MerkleProof struct {
+	BlockHash  string       `json:"blockHash"`
+	MerkleRoot string       `json:"merkleRoot"`
+	TxID       string       `json:"txid"`
+	Index      int          `json:"index"`
+	Count      int          `json:"count"`
+	Branch     []MerkleNode `json:"branch"`
+}
+
+func hashLeaf(txHash []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{MERKLE_LEAF_TAG})
+	h.Write(txHash)
+	return h.Sum(nil)
+}
+
+func hashPair(left []byte, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{MERKLE_INTERIOR_TAG})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// nextMerkleLevel hashes adjacent pairs. The last hash of a level with an
+// odd number of entries moves up unchanged: pairing it with itself would
+// give a list ending in a repeated transaction the same root.
+func nextMerkleLevel(level [][]byte) [][]byte {
+	next := make([][]byte, 0, (len(level)+1)/2)
+	for i := 0; i < len(level); i += 2 {
+		if i+1 == len(level) {
+			next = append(next, level[i])
+			break
+		}
+		next = append(next, hashPair(level[i], level[i+1]))
+	}
+	return next
+}
+
+func merkleLeaves(transactions []*Transaction) [][]byte {
+	leaves := make([][]byte, len(transactions))
+	for i, t := range transactions {
+		h := t.Hash()
+		leaves[i] = hashLeaf(h[:])
+	}
+	return leaves
+}
+
+// MerkleRoot returns the hex Merkle root of the given transactions. A block
+// without transactions has an all-zero root.
+func MerkleRoot(transactions []*Transaction) string {
+	if len(transactions) == 0 {
+		return hex.EncodeToString(make([]byte, sha256.Size))
+	}
+	level := merkleLeaves(transactions)
+	for len(level) > 1 {
+		level = nextMerkleLevel(level)
+	}
+	return hex.EncodeToString(level[0])
+}
+
+// MerkleProof builds the branch proving that the transaction with the given
+// ID is part of the block.
+func (b *Block) MerkleProof(txid string) (*MerkleProof, error) {
+	index := -1
+	for i, t := range b.transactions {
+		if t.ID() == txid {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("transaction %s not found in block %s", txid, b.hash)
+	}
+
+	proof := &MerkleProof{
+		BlockHash:  b.hash,
+		MerkleRoot: b.merkleRoot,
+		TxID:       txid,
+		Index:      index,
+		Count:      len(b.transactions),
+		Branch:     []MerkleNode{},
+	}
+	level := merkleLeaves(b.transactions)
+	for pos := index; len(level) > 1; pos /= 2 {
+		switch {
+		case pos%2 == 1:
+			proof.Branch = append(proof.Branch, MerkleNode{Hash: hex.EncodeToString(level[pos-1]), Position: MERKLE_LEFT})
+		case pos+1 < len(level):
+			proof.Branch = append(proof.Branch, MerkleNode{Hash: hex.EncodeToString(level[pos+1]), Position: MERKLE_RIGHT})
+		}
+		level = nextMerkleLevel(level)
+	}
+	return proof, nil
+}
+
+// VerifyMerkleProof folds the proof's branch over its transaction ID and
+// reports whether the result equals the given Merkle root. The side of each
+// sibling follows from the proof's Index and Count; a branch that disagrees
+// with them, or is longer or shorter than their path, does not verify.
+func VerifyMerkleProof(proof *MerkleProof, merkleRoot string) bool {
+	if proof.Index < 0 || proof.Index >= proof.Count {
+		return false
+	}
+	txHash, err := hex.DecodeString(proof.TxID)
+	if err != nil || len(txHash) != sha256.Size {
+		return false
+	}
+	current := hashLeaf(txHash)
+	branch := proof.Branch
+	for pos, size := proof.Index, proof.Count; size > 1; pos, size = pos/2, (size+1)/2 {
+		position := MERKLE_LEFT
+		if pos%2 == 0 {
+			if pos+1 == size {
+				// Moved up without a sibling
+				continue
+			}
+			position = MERKLE_RIGHT
+		}
+		if len(branch) == 0 || branch[0].Position != position {
+			return false
+		}
+		sibling, err := hex.DecodeString(branch[0].Hash)
+		if err != nil || len(sibling) != sha256.Size {
+			return false
+		}
+		branch = branch[1:]
+		if position == MERKLE_LEFT {
+			current = hashPair(sibling, current)
+		} else {
+			current = hashPair(current, sibling)
+		}
+	}
+	if len(branch) != 0 {
+		return false
+	}
+	root, err := hex.DecodeString(merkleRoot)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(current, root)
+}
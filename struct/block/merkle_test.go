@@ -0,0 +1,103 @@
+package block
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+)
+
+func testTransactions(n int) []*Transaction {
+	transactions := make([]*Transaction, n)
+	for i := range transactions {
+		transactions[i] = NewTransaction("sender", "recipient", fmt.Sprintf("transaction %d", i), 1, 0, uint64(i))
+	}
+	return transactions
+}
+
+func testBlock(transactions []*Transaction) *Block {
+	return NewBlock(1, transactions, "00", 0)
+}
+
+func TestMerkleProofRoundTrip(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		b := testBlock(testTransactions(n))
+		for i, tx := range b.GetTransactions() {
+			proof, err := b.MerkleProof(tx.ID())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if proof.Index != i || proof.Count != n || proof.MerkleRoot != b.GetMerkleRoot() {
+				t.Fatalf("proof of transaction %d of %d claims index %d of %d", i, n, proof.Index, proof.Count)
+			}
+			if !VerifyMerkleProof(proof, b.GetMerkleRoot()) {
+				t.Fatalf("proof of transaction %d of %d does not verify", i, n)
+			}
+		}
+	}
+	if _, err := testBlock(testTransactions(3)).MerkleProof(hex.EncodeToString(make([]byte, sha256.Size))); err == nil {
+		t.Fatal("MerkleProof of a missing transaction succeeded")
+	}
+}
+
+func TestMerkleProofTampered(t *testing.T) {
+	b := testBlock(testTransactions(7))
+	root := b.GetMerkleRoot()
+	other := b.GetTransactions()[3].ID()
+	tamper := map[string]func(p *MerkleProof){
+		"index":          func(p *MerkleProof) { p.Index = 5 },
+		"negative index": func(p *MerkleProof) { p.Index = -1 },
+		"index past end": func(p *MerkleProof) { p.Index = 7 },
+		"count":          func(p *MerkleProof) { p.Count = 8 },
+		"txid":           func(p *MerkleProof) { p.TxID = other },
+		"position":       func(p *MerkleProof) { p.Branch[0].Position = MERKLE_RIGHT },
+		"sibling":        func(p *MerkleProof) { p.Branch[1].Hash = other },
+		"short branch":   func(p *MerkleProof) { p.Branch = p.Branch[:len(p.Branch)-1] },
+		"long branch":    func(p *MerkleProof) { p.Branch = append(p.Branch, MerkleNode{Hash: other, Position: MERKLE_RIGHT}) },
+		"bad hex":        func(p *MerkleProof) { p.Branch[0].Hash = "zz" },
+	}
+	for name, f := range tamper {
+		// The last transaction moves up without a sibling, so its path
+		// depends on the count too
+		proof, err := b.MerkleProof(b.GetTransactions()[6].ID())
+		if err != nil {
+			t.Fatal(err)
+		}
+		f(proof)
+		if VerifyMerkleProof(proof, root) {
+			t.Errorf("proof with a tampered %s verifies", name)
+		}
+	}
+}
+
+func TestMerkleRootDuplicateTail(t *testing.T) {
+	// Pairing an odd last node with itself gives [a b c] and [a b c c] the
+	// same root (CVE-2012-2459)
+	transactions := testTransactions(3)
+	if MerkleRoot(transactions) == MerkleRoot(append(transactions, transactions[2])) {
+		t.Fatal("repeating the last transaction keeps the Merkle root")
+	}
+	transactions = testTransactions(6)
+	mutated := append(append([]*Transaction(nil), transactions...), transactions[4:]...)
+	if MerkleRoot(transactions) == MerkleRoot(mutated) {
+		t.Fatal("repeating the last two transactions keeps the Merkle root")
+	}
+}
+
+func TestMerkleRootDomainSeparation(t *testing.T) {
+	transactions := testTransactions(2)
+	h0, h1 := transactions[0].Hash(), transactions[1].Hash()
+
+	// A single leaf is tagged, not the bare transaction hash
+	if MerkleRoot(transactions[:1]) == hex.EncodeToString(h0[:]) {
+		t.Fatal("the root of one transaction is its bare hash")
+	}
+	// An interior node is not the untagged hash of its children
+	untagged := sha256.Sum256(append(hashLeaf(h0[:]), hashLeaf(h1[:])...))
+	if MerkleRoot(transactions) == hex.EncodeToString(untagged[:]) {
+		t.Fatal("interior nodes are hashed without their tag")
+	}
+	if MerkleRoot(nil) != hex.EncodeToString(make([]byte, sha256.Size)) {
+		t.Fatal("the root of no transactions is not all zeros")
+	}
+}
@@ -4,100 +4,213 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"strings"
 	"time"
 )
 
+// Mining mines the pooled transactions into a block, saves it and asks the
+// neighbors to resolve conflicts so they pick it up.
 func (bc *Blockchain) Mining() bool {
-
 	bc.mux.Lock()
-	defer bc.mux.Unlock()
-	if len(bc.transactionPool) == 0 {
+	for _, t := range bc.pool.Expire() {
+		log.Printf("WARNING: Pooled transaction %s expired", t.ID())
+	}
+	if bc.pool.Count() == 0 {
+		bc.mux.Unlock()
 		return false
 	}
-	_, err := bc.AddTransaction(MINING_SENDER, bc.blockchainAddress, "MINING REWARD", MINING_REWARD, nil, nil)
+	b, err := bc.mineBlock(bc.blockchainAddress)
 	if err != nil {
+		bc.mux.Unlock()
 		log.Printf("ERROR: %v", err)
 		return false
 	}
-	previousHash := bc.LastBlock().GetHash()
-	bc.CreateBlock(bc.transactionPool, previousHash)
 	if err := bc.SaveBlockchain(); err != nil {
 		log.Printf("ERROR: Failed to save blockchain after mining: %v", err)
 	}
-	log.Println("action=mining, status=success")
-	for _, n := range bc.neighbors {
-		fmt.Println("Send consensus to neigbour ", n)
-		endpoint := fmt.Sprintf("%s/consensus", n)
-		client := &http.Client{}
-		req, _ := http.NewRequest("PUT", endpoint, nil)
+	bc.mux.Unlock()
+	log.Printf("action=mining, status=success, height=%d", b.GetHeight())
+
+	bc.notifyNeighbors()
+	return true
+}
+
+// notifyNeighbors sends PUT /consensus to every neighbor. A neighbor that
+// fails to answer does not keep the others from being notified.
+func (bc *Blockchain) notifyNeighbors() {
+	neighbors := bc.GetNeighbors()
+	client := &http.Client{Timeout: CONSENSUS_NOTIFY_TIMEOUT_SEC * time.Second}
+	for _, n := range neighbors {
+		req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/consensus", n), nil)
+		if err != nil {
+			log.Printf("ERROR: Failed to build consensus request for neighbor %s: %v", n, err)
+			continue
+		}
 		resp, err := client.Do(req)
 		if err != nil {
-			log.Printf("ERROR: %v", err)
-			return false
+			log.Printf("WARNING: Failed to send consensus to neighbor %s: %v", n, err)
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			log.Printf("WARNING: Neighbor %s answered consensus with status %d", n, resp.StatusCode)
+			continue
 		}
-		log.Printf("%v", resp)
+		log.Printf("INFO: Sent consensus to neighbor %s", n)
 	}
-	return true
 }
 
+// mineBlock mines a block on top of the chain holding the extra transactions,
+// then the pooled transactions that pay the best fee rates and are valid
+// against the current state, followed by a coinbase paying minerAddress the
+// subsidy scheduled at the new height plus their fees. Callers must hold
+// bc.mux.
+func (bc *Blockchain) mineBlock(minerAddress string, extra ...*Transaction) (*Block, error) {
+	if bc.state == nil {
+		return nil, fmt.Errorf("current chain state is unavailable")
+	}
+
+	sizeLimit := bc.params.MaxBlockSize - COINBASE_RESERVED_SIZE
+	for _, t := range extra {
+		sizeLimit -= t.Size()
+	}
+	selected, fees := selectTransactions(bc.state.copy(), bc.TransactionPool(), sizeLimit)
+	transactions := append(extra, selected...)
+
+	height := bc.LastBlock().GetHeight() + 1
+	reward, err := bc.params.BlockSubsidy(height, bc.state.Supply()).Add(fees)
+	if err != nil {
+		return nil, fmt.Errorf("coinbase: %v", err)
+	}
+
+	// The coinbase nonce is the block height, which keeps coinbase IDs unique
+	transactions = append(transactions, NewTransaction(MINING_SENDER, minerAddress, "MINING REWARD", reward, 0, height))
+
+	return bc.CreateBlock(transactions, bc.LastBlock().GetHash())
+}
+
+// StartMining mines pooled transactions every configured mining interval
+// until StopMining. Starting a running miner does nothing.
 func (bc *Blockchain) StartMining() {
-	bc.Mining()
-	_ = time.AfterFunc(time.Second*MINING_TIMER_SEC, bc.StartMining)
+	bc.muxMining.Lock()
+	if bc.mining {
+		bc.muxMining.Unlock()
+		return
+	}
+	bc.mining = true
+	bc.miningRun++
+	run := bc.miningRun
+	bc.muxMining.Unlock()
+	bc.miningLoop(run)
 }
 
-// ValidProof validates the proof of work.
-func (bc *Blockchain) ValidProof(nonce int, previousHash string, transactions []*Transaction, difficulty int) bool {
-	zeros := strings.Repeat("0", difficulty)
-	guessBlock := NewBlock(transactions, previousHash)
-	guessBlock.SetNonce(nonce)
-	guessHash := guessBlock.CalculateHash()
-	return strings.HasPrefix(guessHash, zeros)
+// miningLoop mines a block and schedules the next one, unless the loop was
+// stopped, or stopped and restarted, meanwhile.
+func (bc *Blockchain) miningLoop(run uint64) {
+	bc.Mining()
+
+	bc.muxMining.Lock()
+	defer bc.muxMining.Unlock()
+	if bc.mining && bc.miningRun == run {
+		bc.miningTimer = time.AfterFunc(bc.config.MiningInterval(), func() { bc.miningLoop(run) })
+	}
 }
 
-// ProofOfWork finds the proof of work.
-func (bc *Blockchain) ProofOfWork() int {
-	transactions := bc.CopyTransactionPool()
-	previousHash := bc.LastBlock().GetHash()
-	nonce := 0
-	for !bc.ValidProof(nonce, previousHash, transactions, MINING_DIFFICULTY) {
-		nonce += 1
+// StopMining stops the mining loop. A block being mined is finished.
+func (bc *Blockchain) StopMining() {
+	bc.muxMining.Lock()
+	defer bc.muxMining.Unlock()
+	bc.mining = false
+	if bc.miningTimer != nil {
+		bc.miningTimer.Stop()
+		bc.miningTimer = nil
 	}
-	return nonce
 }
 
-func (bc *Blockchain) ValidChain(chain []*Block) bool {
+// IsMining reports whether the mining loop is running.
+func (bc *Blockchain) IsMining() bool {
+	bc.muxMining.Lock()
+	defer bc.muxMining.Unlock()
+	return bc.mining
+}
+
+// VerifyChain checks that the chain starts from this network's genesis block
+// and that every later block's stored header hashes to its stored hash,
+// links to its predecessor, sits at the right height, has a sane timestamp
+// and carries and meets the difficulty expected at its height. It then
+// replays every transaction, checking signatures, balances and coinbase
+// rules. The returned error names the offending block and transaction.
+func (bc *Blockchain) VerifyChain(chain []*Block) error {
+	if len(chain) == 0 {
+		return fmt.Errorf("chain has no genesis block")
+	}
 
 	preBlock := chain[0]
+	if preBlock.GetHash() != bc.genesisHash || preBlock.GetHash() != preBlock.CalculateHash() {
+		return blockError(0, "genesis block %s does not match this network's genesis block %s", preBlock.GetHash(), bc.genesisHash)
+	}
+
 	currentIndex := 1
 	for currentIndex < len(chain) {
 		b := chain[currentIndex]
+		height := uint64(currentIndex)
+		if b.GetHeight() != height {
+			return blockError(height, "block %s has height %d", b.GetHash(), b.GetHeight())
+		}
+
 		if b.GetPrevHash() != preBlock.GetHash() {
-			return false
+			return blockError(height, "does not link to %s", preBlock.GetHash())
+		}
+
+		if b.GetHash() != b.CalculateHash() {
+			return blockError(height, "hash does not match its header")
+		}
+
+		if b.GetTimestamp() < preBlock.GetTimestamp() ||
+			b.GetTimestamp() > time.Now().Unix()+bc.params.MaxFutureBlockTimeSec {
+			return blockError(height, "out of range timestamp %d", b.GetTimestamp())
+		}
+
+		expected := bc.params.ExpectedDifficulty(chain, height)
+		if b.GetDifficulty() != expected {
+			return blockError(height, "has difficulty %d, expected %d", b.GetDifficulty(), expected)
 		}
 
-		if !bc.ValidProof(b.GetNonce(), b.GetPrevHash(), b.GetTransactions(), MINING_DIFFICULTY) {
-			return false
+		if !b.IsValidHash() {
+			return blockError(height, "hash does not meet difficulty %d", expected)
 		}
 
 		preBlock = b
 		currentIndex += 1
 	}
+
+	if _, err := replayChain(chain, bc.genesis); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ValidChain reports whether the chain passes VerifyChain, logging the
+// reason when it does not.
+func (bc *Blockchain) ValidChain(chain []*Block) bool {
+	if err := bc.VerifyChain(chain); err != nil {
+		log.Printf("ERROR: Invalid chain: %v", err)
+		return false
+	}
 	return true
 }
 
 func (bc *Blockchain) RegisterNewWallet(blockchainAddress string, message string) bool {
-	// Add the registration transaction
-	_, err := bc.AddTransaction(MINING_SENDER, blockchainAddress, message, 0, nil, nil)
-	if err != nil {
+	bc.mux.Lock()
+	defer bc.mux.Unlock()
+
+	// Mine a new block carrying the registration, numbered by its height
+	height := bc.LastBlock().GetHeight() + 1
+	registration := NewTransaction(MINING_SENDER, blockchainAddress, message, 0, 0, height)
+	if _, err := bc.mineBlock(bc.blockchainAddress, registration); err != nil {
 		log.Printf("ERROR: %v", err)
 		return false
 	}
 
-	// Create a new block with just the registration transaction
-	previousHash := bc.LastBlock().GetHash()
-	bc.CreateBlock(bc.transactionPool, previousHash)
-
 	// Save the blockchain state
 	if err := bc.SaveBlockchain(); err != nil {
 		log.Printf("ERROR: Failed to save blockchain after wallet registration: %v", err)
@@ -106,30 +219,3 @@ func (bc *Blockchain) RegisterNewWallet(blockchainAddress string, message string
 
 	return true
 }
-
-func (bc *Blockchain) CalculateTotalBalance(blockchainAddress string) (float32, error) {
-	var totalBalance float32 = 0.0
-	addressFound := false
-
-	for _, b := range bc.chain {
-		for _, t := range b.transactions {
-			value := t.value
-
-			if blockchainAddress == t.recipientBlockchainAddress {
-				totalBalance += value
-				addressFound = true
-			}
-
-			if blockchainAddress == t.senderBlockchainAddress {
-				totalBalance -= value
-				addressFound = true
-			}
-		}
-	}
-
-	if !addressFound {
-		return 0.0, fmt.Errorf("Address not found in the Blockchain")
-	}
-
-	return totalBalance, nil
-}
@@ -2,26 +2,56 @@ package block
 
 import (
 	"block/struct/utils"
-	"os"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// SetNeighbors uses the configured peers, or probes the configured IP and
+// port ranges around this node when there are none.
 func (bc *Blockchain) SetNeighbors() {
-	if host := os.Getenv("MINER_HOST"); host != "" {
-		bc.neighbors = []string{
-			"http://" + host + "-1:5001",
-			"http://" + host + "-2:5002",
-			"http://" + host + "-3:5003",
-		}
+	if len(bc.config.Peers) > 0 {
+		bc.neighbors = append([]string(nil), bc.config.Peers...)
 	} else {
+		ips, ports := bc.config.NeighborIPs, bc.config.NeighborPorts
 		bc.neighbors = utils.FindNeighbors(
-			utils.GetHost(), bc.port,
-			NEIGHBOR_IP_RANGE_START, NEIGHBOR_IP_RANGE_END,
-			BLOCKCHAIN_PORT_RANGE_START, BLOCKCHAIN_PORT_RANGE_END)
+			utils.GetHost(), bc.config.Port,
+			uint8(ips.Start), uint8(ips.End),
+			ports.Start, ports.End)
 	}
-	bc.neighbors = filterOutSelfPort(bc.neighbors, strconv.Itoa(int(bc.port)))
+	bc.neighbors = filterOutSelfPort(bc.neighbors, strconv.Itoa(int(bc.config.Port)))
+	bc.neighbors = bc.filterOtherNetworks(bc.neighbors)
+}
+
+// filterOtherNetworks keeps the neighbors that report the same genesis block
+// as ours.
+func (bc *Blockchain) filterOtherNetworks(neighbors []string) []string {
+	client := &http.Client{Timeout: GENESIS_CHECK_TIMEOUT_SEC * time.Second}
+	var filtered []string
+	for _, n := range neighbors {
+		resp, err := client.Get(fmt.Sprintf("%s/genesis", n))
+		if err != nil {
+			log.Printf("WARNING: Failed to fetch genesis of neighbor %s: %v", n, err)
+			continue
+		}
+		var g GenesisResponse
+		err = json.NewDecoder(resp.Body).Decode(&g)
+		resp.Body.Close()
+		if err != nil {
+			log.Printf("WARNING: Failed to decode genesis of neighbor %s: %v", n, err)
+			continue
+		}
+		if g.Hash != bc.genesisHash {
+			log.Printf("WARNING: Rejecting neighbor %s: genesis %s (chain %q) differs from ours %s", n, g.Hash, g.ChainID, bc.genesisHash)
+			continue
+		}
+		filtered = append(filtered, n)
+	}
+	return filtered
 }
 
 // * This is a debug method, until blockchain broadcasting is implemented
@@ -46,5 +76,5 @@ func (bc *Blockchain) SyncNeighbors() {
 // StartSyncNeighbors initiates the synchronization process and schedules it to run periodically.
 func (bc *Blockchain) StartSyncNeighbors() {
 	bc.SyncNeighbors()
-	_ = time.AfterFunc(time.Second*BLOCKCHIN_NEIGHBOR_SYNC_TIME_SEC, bc.StartSyncNeighbors)
+	_ = time.AfterFunc(bc.config.NeighborSyncInterval(), bc.StartSyncNeighbors)
 }
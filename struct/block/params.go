@@ -0,0 +1,119 @@
+package block
+
+import (
+	"block/struct/amount"
+	"fmt"
+)
+
+// ChainParams holds the consensus rules that every node on a network must
+// agree on.
+type ChainParams struct {
+	// InitialDifficulty is the difficulty, in leading zero bits of the block
+	// hash, of the genesis block and of every block before the first retarget.
+	InitialDifficulty int `json:"initialDifficulty"`
+	// MinDifficulty and MaxDifficulty bound every retarget.
+	MinDifficulty int `json:"minDifficulty"`
+	MaxDifficulty int `json:"maxDifficulty"`
+	// TargetBlockTimeSec is the block interval retargeting steers toward.
+	TargetBlockTimeSec int64 `json:"targetBlockTimeSec"`
+	// RetargetInterval is the number of blocks between difficulty adjustments.
+	RetargetInterval uint64 `json:"retargetInterval"`
+	// MaxRetargetStep caps how many bits a single retarget may move the
+	// difficulty in either direction.
+	MaxRetargetStep int `json:"maxRetargetStep"`
+	// MaxFutureBlockTimeSec is how far ahead of the local clock a block
+	// timestamp may be.
+	MaxFutureBlockTimeSec int64 `json:"maxFutureBlockTimeSec"`
+	// InitialSubsidy is the amount newly minted by the coinbase of every
+	// block, on top of its fees, before the first halving.
+	InitialSubsidy amount.Amount `json:"initialSubsidy"`
+	// HalvingInterval is the number of blocks after which the subsidy halves.
+	// Zero keeps the subsidy constant.
+	HalvingInterval uint64 `json:"halvingInterval"`
+	// MaxSupply caps the total amount ever minted.
+	MaxSupply amount.Amount `json:"maxSupply"`
+	// CoinbaseMaturity is the number of blocks a coinbase stays locked: a
+	// reward minted at height h can be spent from height h+CoinbaseMaturity
+	// on.
+	CoinbaseMaturity uint64 `json:"coinbaseMaturity"`
+	// MaxBlockSize limits the summed encoded size of a block's transactions.
+	MaxBlockSize int `json:"maxBlockSize"`
+}
+
+// DefaultChainParams returns the parameters used when none are configured.
+func DefaultChainParams() *ChainParams {
+	return &ChainParams{
+		InitialDifficulty:     MINING_DIFFICULTY,
+		MinDifficulty:         MINING_MIN_DIFFICULTY,
+		MaxDifficulty:         MINING_MAX_DIFFICULTY,
+		TargetBlockTimeSec:    MINING_TARGET_BLOCK_TIME_SEC,
+		RetargetInterval:      MINING_RETARGET_INTERVAL,
+		MaxRetargetStep:       MINING_MAX_RETARGET_STEP,
+		MaxFutureBlockTimeSec: MAX_FUTURE_BLOCK_TIME_SEC,
+		InitialSubsidy:        amount.MustFromCoins(MINING_INITIAL_SUBSIDY),
+		HalvingInterval:       MINING_HALVING_INTERVAL,
+		MaxSupply:             amount.MustFromCoins(MAX_SUPPLY),
+		CoinbaseMaturity:      COINBASE_MATURITY,
+		MaxBlockSize:          MAX_BLOCK_SIZE,
+	}
+}
+
+// Validate checks that the parameters describe a workable chain.
+func (p *ChainParams) Validate() error {
+	switch {
+	case p.MinDifficulty < 0 || p.MaxDifficulty > 256 || p.MinDifficulty > p.MaxDifficulty:
+		return fmt.Errorf("difficulty bounds %d..%d are invalid", p.MinDifficulty, p.MaxDifficulty)
+	case p.InitialDifficulty < p.MinDifficulty || p.InitialDifficulty > p.MaxDifficulty:
+		return fmt.Errorf("initial difficulty %d is outside %d..%d", p.InitialDifficulty, p.MinDifficulty, p.MaxDifficulty)
+	case p.TargetBlockTimeSec <= 0:
+		return fmt.Errorf("target block time must be positive")
+	case p.MaxRetargetStep < 0:
+		return fmt.Errorf("max retarget step must not be negative")
+	case p.MaxFutureBlockTimeSec < 0:
+		return fmt.Errorf("max future block time must not be negative")
+	case p.MaxSupply == 0:
+		return fmt.Errorf("max supply must be positive")
+	case p.MaxBlockSize <= COINBASE_RESERVED_SIZE:
+		return fmt.Errorf("max block size must exceed the %d bytes reserved for the coinbase", COINBASE_RESERVED_SIZE)
+	}
+	return nil
+}
+
+// Subsidy returns the amount scheduled to be minted by the block at height:
+// the initial subsidy, halved once for every HalvingInterval blocks. The
+// genesis block has no subsidy.
+func (p *ChainParams) Subsidy(height uint64) amount.Amount {
+	if height == 0 {
+		return 0
+	}
+	if p.HalvingInterval == 0 {
+		return p.InitialSubsidy
+	}
+	halvings := height / p.HalvingInterval
+	if halvings >= 64 {
+		return 0
+	}
+	return p.InitialSubsidy >> halvings
+}
+
+// BlockSubsidy returns the scheduled subsidy at height, reduced so that
+// minting it on top of supply does not exceed MaxSupply.
+func (p *ChainParams) BlockSubsidy(height uint64, supply amount.Amount) amount.Amount {
+	if supply >= p.MaxSupply {
+		return 0
+	}
+	subsidy := p.Subsidy(height)
+	if left := p.MaxSupply - supply; subsidy > left {
+		return left
+	}
+	return subsidy
+}
+
+// NextHalvingHeight returns the first height after height at which the
+// subsidy halves, or 0 if it never does.
+func (p *ChainParams) NextHalvingHeight(height uint64) uint64 {
+	if p.HalvingInterval == 0 {
+		return 0
+	}
+	return (height/p.HalvingInterval + 1) * p.HalvingInterval
+}
@@ -0,0 +1,109 @@
+package block
+
+import (
+	"block/struct/amount"
+	"testing"
+)
+
+func TestSubsidyHalving(t *testing.T) {
+	p := &ChainParams{InitialSubsidy: 1000, HalvingInterval: 10, MaxSupply: amount.MustFromCoins(1)}
+	tests := map[uint64]amount.Amount{
+		0:   0,
+		1:   1000,
+		9:   1000,
+		10:  500,
+		19:  500,
+		20:  250,
+		30:  125,
+		40:  62,
+		100: 0,
+		640: 0,
+	}
+	for height, want := range tests {
+		if got := p.Subsidy(height); got != want {
+			t.Errorf("Subsidy(%d) = %s, want %s", height, got, want)
+		}
+	}
+	for height, want := range map[uint64]uint64{0: 10, 9: 10, 10: 20, 25: 30} {
+		if got := p.NextHalvingHeight(height); got != want {
+			t.Errorf("NextHalvingHeight(%d) = %d, want %d", height, got, want)
+		}
+	}
+
+	// Without a halving interval the subsidy never changes
+	p.HalvingInterval = 0
+	if p.Subsidy(1<<40) != 1000 || p.NextHalvingHeight(5) != 0 {
+		t.Error("subsidy changes without a halving interval")
+	}
+}
+
+func TestBlockSubsidyCap(t *testing.T) {
+	p := &ChainParams{InitialSubsidy: 1000, HalvingInterval: 10, MaxSupply: 2500}
+	tests := []struct {
+		height uint64
+		supply amount.Amount
+		want   amount.Amount
+	}{
+		{1, 0, 1000},
+		{1, 1500, 1000},
+		{1, 1600, 900},
+		{1, 2499, 1},
+		{1, 2500, 0},
+		{1, 3000, 0},
+		{10, 2200, 300},
+	}
+	for _, tt := range tests {
+		if got := p.BlockSubsidy(tt.height, tt.supply); got != tt.want {
+			t.Errorf("BlockSubsidy(%d, %s) = %s, want %s", tt.height, tt.supply, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultEmission(t *testing.T) {
+	// Summed over every halving the default schedule stays within the cap
+	p := DefaultChainParams()
+	var total amount.Amount
+	for h := uint64(0); p.Subsidy(h*p.HalvingInterval+1) > 0; h++ {
+		minted, err := p.Subsidy(h*p.HalvingInterval + 1).MulInt(p.HalvingInterval)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total, err = total.Add(minted); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if total > p.MaxSupply {
+		t.Fatalf("default schedule mints %s, more than the maximum supply %s", total, p.MaxSupply)
+	}
+}
+
+func TestApplyBlockSupplyCap(t *testing.T) {
+	s := newTestState(t)
+	params := *s.genesis.Params
+	params.MaxSupply = amount.MustFromCoins(10) + 150
+	params.InitialSubsidy = 100
+	s.state.params = &params
+	miner := newTestAddress(t)
+
+	// The first block mints the full subsidy, the second only what is left
+	if err := s.apply(1, coinbase(miner, 100, 1)); err != nil {
+		t.Fatal(err)
+	}
+	s.rejects(t, "coinbase minting past the cap", 0, 2, coinbase(miner, 100, 2))
+	if err := s.apply(2, coinbase(miner, 50, 2)); err != nil {
+		t.Fatal(err)
+	}
+	if s.state.Supply() != params.MaxSupply {
+		t.Fatalf("supply = %s, want the maximum %s", s.state.Supply(), params.MaxSupply)
+	}
+
+	// From then on a coinbase only collects fees
+	s.rejects(t, "coinbase minting at the cap", 0, 3, coinbase(miner, 1, 3))
+	tx := s.transfer(t, newTestAddress(t), 1000, 25, 0)
+	if err := s.apply(3, tx, coinbase(miner, 25, 3)); err != nil {
+		t.Fatal(err)
+	}
+	if s.state.Supply() != params.MaxSupply {
+		t.Fatalf("supply = %s after a fee-only block, want %s", s.state.Supply(), params.MaxSupply)
+	}
+}
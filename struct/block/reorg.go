@@ -0,0 +1,133 @@
+package block
+
+import (
+	"log"
+	"time"
+)
+
+// MAX_REORG_EVENTS bounds how many reorganizations are remembered.
+const MAX_REORG_EVENTS = 100
+
+// ReorgEvent records a switch to a chain that abandoned some of our blocks.
+type ReorgEvent struct {
+	Time          int64    `json:"time"`
+	ForkHeight    uint64   `json:"forkHeight"`
+	ForkHash      string   `json:"forkHash"`
+	Depth         int      `json:"depth"`
+	OldTip        string   `json:"oldTip"`
+	OldHeight     uint64   `json:"oldHeight"`
+	NewTip        string   `json:"newTip"`
+	NewHeight     uint64   `json:"newHeight"`
+	Restored      int      `json:"restoredTransactions"`
+	Dropped       int      `json:"droppedTransactions"`
+	RestoredTxIDs []string `json:"restoredTransactionIds"`
+}
+
+// forkPoint returns the index of the last block two chains share, or -1 if
+// not even their genesis blocks match.
+func forkPoint(a []*Block, b []*Block) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i].GetHash() == b[i].GetHash() {
+		i++
+	}
+	return i - 1
+}
+
+// replaceChain switches to newChain. User transactions that were only
+// confirmed in the abandoned blocks are re-validated and returned to the
+// pool ahead of the transactions already pending there. If blocks were
+// abandoned, the reorganization is recorded and returned. A chain that does
+// not replay is refused and nothing changes. Callers must hold bc.mux.
+func (bc *Blockchain) replaceChain(newChain []*Block) (*ReorgEvent, error) {
+	state, err := replayChain(newChain, bc.genesis)
+	if err != nil {
+		return nil, err
+	}
+
+	oldChain := bc.chain
+	fork := forkPoint(oldChain, newChain)
+	abandoned := oldChain[fork+1:]
+
+	adopted := make(map[string]bool)
+	for _, b := range newChain[fork+1:] {
+		for _, t := range b.transactions {
+			adopted[t.ID()] = true
+		}
+	}
+
+	var orphans []*Transaction
+	for _, b := range abandoned {
+		for _, t := range b.transactions {
+			if t.senderBlockchainAddress != MINING_SENDER && !adopted[t.ID()] {
+				orphans = append(orphans, t)
+			}
+		}
+	}
+
+	pending := bc.TransactionPool()
+	bc.chain = newChain
+	bc.state = state
+	bc.pool.Clear()
+
+	dropped := 0
+	readmit := func(t *Transaction) bool {
+		if adopted[t.ID()] {
+			return false
+		}
+		if !t.VerifySignature() {
+			dropped++
+			return false
+		}
+		if err := bc.pool.Add(t, bc.state); err != nil {
+			log.Printf("WARNING: Dropped transaction %s after reorganization: %v", t.ID(), err)
+			dropped++
+			return false
+		}
+		return true
+	}
+
+	restored := []string{}
+	for _, t := range orphans {
+		if readmit(t) {
+			restored = append(restored, t.ID())
+		}
+	}
+	for _, t := range pending {
+		readmit(t)
+	}
+
+	if len(abandoned) == 0 {
+		return nil, nil
+	}
+
+	event := &ReorgEvent{
+		Time:          time.Now().Unix(),
+		Depth:         len(abandoned),
+		OldTip:        oldChain[len(oldChain)-1].GetHash(),
+		OldHeight:     oldChain[len(oldChain)-1].GetHeight(),
+		NewTip:        newChain[len(newChain)-1].GetHash(),
+		NewHeight:     newChain[len(newChain)-1].GetHeight(),
+		Restored:      len(restored),
+		Dropped:       dropped,
+		RestoredTxIDs: restored,
+	}
+	if fork >= 0 {
+		event.ForkHeight = newChain[fork].GetHeight()
+		event.ForkHash = newChain[fork].GetHash()
+	}
+
+	bc.reorgs = append(bc.reorgs, event)
+	if len(bc.reorgs) > MAX_REORG_EVENTS {
+		bc.reorgs = bc.reorgs[len(bc.reorgs)-MAX_REORG_EVENTS:]
+	}
+	log.Printf("INFO: Reorganized %d blocks from %s to %s at fork height %d, restored %d transactions",
+		event.Depth, event.OldTip, event.NewTip, event.ForkHeight, event.Restored)
+	return event, nil
+}
+
+// Reorgs returns the recorded reorganizations, oldest first.
+func (bc *Blockchain) Reorgs() []*ReorgEvent {
+	bc.mux.Lock()
+	defer bc.mux.Unlock()
+	return append([]*ReorgEvent{}, bc.reorgs...)
+}
@@ -0,0 +1,141 @@
+package block
+
+import (
+	"block/struct/amount"
+	"reflect"
+	"testing"
+)
+
+func poolIDs(bc *Blockchain) []string {
+	ids := []string{}
+	for _, t := range bc.TransactionPool() {
+		ids = append(ids, t.ID())
+	}
+	return ids
+}
+
+func replace(t *testing.T, bc *Blockchain, chain []*Block) *ReorgEvent {
+	t.Helper()
+	bc.mux.Lock()
+	defer bc.mux.Unlock()
+	event, err := bc.replaceChain(chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func TestReplaceChain(t *testing.T) {
+	key, sender := newTestKey(t)
+	recipient := newTestAddress(t)
+	g := testGenesis(sender)
+	local := newTestBlockchain(t, g)
+	remote := newTestBlockchain(t, g)
+
+	// Both forks confirm shared; only the local one confirms orphan
+	shared := signedTransfer(t, key, g.ChainID, recipient, 1000, 10, 0)
+	orphan := signedTransfer(t, key, g.ChainID, recipient, 2000, 10, 1)
+	submit(t, local, shared)
+	submit(t, local, orphan)
+	mine(t, local, newTestAddress(t), 1)
+	pending := signedTransfer(t, key, g.ChainID, recipient, 3000, 10, 2)
+	submit(t, local, pending)
+
+	submit(t, remote, shared)
+	mine(t, remote, newTestAddress(t), 2)
+
+	oldTip := local.LastBlock()
+	event := replace(t, local, remote.Chain())
+	if local.LastBlock().GetHash() != remote.LastBlock().GetHash() {
+		t.Fatalf("local tip %s, want the heavier fork's tip %s", local.LastBlock().GetHash(), remote.LastBlock().GetHash())
+	}
+
+	// The orphaned transfer goes back to the pool ahead of the pending one;
+	// the transfer both forks confirmed does not
+	ids := poolIDs(local)
+	if len(ids) != 2 || ids[0] != orphan.ID() || ids[1] != pending.ID() {
+		t.Fatalf("pool after the reorganization = %v, want [%s %s]", ids, orphan.ID(), pending.ID())
+	}
+	if b := local.state.Balance(recipient); b != 1000 {
+		t.Fatalf("recipient balance %s on the new fork, want %s", b, amount.Amount(1000))
+	}
+
+	want := ReorgEvent{
+		ForkHeight:    0,
+		ForkHash:      local.GenesisHash(),
+		Depth:         1,
+		OldTip:        oldTip.GetHash(),
+		OldHeight:     1,
+		NewTip:        remote.LastBlock().GetHash(),
+		NewHeight:     2,
+		Restored:      1,
+		Dropped:       0,
+		RestoredTxIDs: []string{orphan.ID()},
+	}
+	if event == nil {
+		t.Fatal("replaceChain recorded no reorganization")
+	}
+	got := *event
+	got.Time = 0
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ReorgEvent = %+v, want %+v", got, want)
+	}
+	if reorgs := local.Reorgs(); len(reorgs) != 1 || reorgs[0] != event {
+		t.Fatalf("Reorgs() = %v, want the one event", reorgs)
+	}
+
+	// Extending the chain abandons nothing and records nothing
+	mine(t, remote, newTestAddress(t), 1)
+	if event := replace(t, local, remote.Chain()); event != nil {
+		t.Fatalf("extending the chain recorded %+v", event)
+	}
+	if len(local.Reorgs()) != 1 {
+		t.Fatalf("Reorgs() has %d events, want 1", len(local.Reorgs()))
+	}
+}
+
+func TestReplaceChainDropsConflicts(t *testing.T) {
+	key, sender := newTestKey(t)
+	g := testGenesis(sender)
+	local := newTestBlockchain(t, g)
+	remote := newTestBlockchain(t, g)
+
+	// The heavier fork spends nonce 0 differently, so the local transfer can
+	// not come back
+	orphan := signedTransfer(t, key, g.ChainID, newTestAddress(t), 1000, 0, 0)
+	submit(t, local, orphan)
+	mine(t, local, newTestAddress(t), 1)
+	submit(t, remote, signedTransfer(t, key, g.ChainID, newTestAddress(t), 1000, 0, 0))
+	mine(t, remote, newTestAddress(t), 2)
+
+	event := replace(t, local, remote.Chain())
+	if event == nil || event.Restored != 0 || event.Dropped != 1 || len(event.RestoredTxIDs) != 0 {
+		t.Fatalf("ReorgEvent = %+v, want the orphan dropped", event)
+	}
+	if ids := poolIDs(local); len(ids) != 0 {
+		t.Fatalf("pool after the reorganization = %v, want it empty", ids)
+	}
+}
+
+func TestReplaceChainRefusesInvalid(t *testing.T) {
+	g := testGenesis()
+	local := newTestBlockchain(t, g)
+	remote := newTestBlockchain(t, g)
+	mine(t, local, newTestAddress(t), 1)
+	mine(t, remote, newTestAddress(t), 2)
+
+	// A fork whose coinbase overpays does not replay
+	chain := remote.Chain()
+	chain = append(chain, nextBlock(chain, g.Params.InitialDifficulty, coinbase(newTestAddress(t), amount.MustFromCoins(1000), 3)))
+	tip := local.LastBlock().GetHash()
+
+	local.mux.Lock()
+	event, err := local.replaceChain(chain)
+	local.mux.Unlock()
+	if err == nil || event != nil {
+		t.Fatalf("replaceChain of an invalid fork = %+v, %v, want an error", event, err)
+	}
+	if local.LastBlock().GetHash() != tip || len(local.Reorgs()) != 0 {
+		t.Fatal("a refused fork changed the chain")
+	}
+}
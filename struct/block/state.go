@@ -0,0 +1,319 @@
+package block
+
+import (
+	"block/struct/address"
+	"block/struct/amount"
+	"fmt"
+)
+
+// ValidationError describes why a chain was rejected. TxIndex is -1 when the
+// problem concerns the block as a whole.
+type ValidationError struct {
+	Height  uint64
+	TxIndex int
+	Reason  string
+}
+
+func (e *ValidationError) Error() string {
+	if e.TxIndex < 0 {
+		return fmt.Sprintf("block %d: %s", e.Height, e.Reason)
+	}
+	return fmt.Sprintf("block %d, transaction %d: %s", e.Height, e.TxIndex, e.Reason)
+}
+
+func blockError(height uint64, format string, a ...interface{}) error {
+	return &ValidationError{Height: height, TxIndex: -1, Reason: fmt.Sprintf(format, a...)}
+}
+
+func txError(height uint64, index int, format string, a ...interface{}) error {
+	return &ValidationError{Height: height, TxIndex: index, Reason: fmt.Sprintf(format, a...)}
+}
+
+// chainState is the account state obtained by replaying transactions in
+// chain order: spendable balances, coinbase rewards that are not mature yet,
+// the next expected nonce of every sender and the total amount minted so far.
+// Balances are what the next block may spend.
+type chainState struct {
+	chainID  string
+	params   *ChainParams
+	balances map[string]amount.Amount
+	immature []*lockedReward
+	nonces   map[string]uint64
+	supply   amount.Amount
+}
+
+// lockedReward is a coinbase payment that cannot be spent before the block
+// at height unlocks.
+type lockedReward struct {
+	address string
+	value   amount.Amount
+	unlocks uint64
+}
+
+func newChainState(chainID string, params *ChainParams) *chainState {
+	return &chainState{
+		chainID:  chainID,
+		params:   params,
+		balances: make(map[string]amount.Amount),
+		nonces:   make(map[string]uint64),
+	}
+}
+
+// Balance returns the confirmed balance of the address that the next block
+// may spend. Immature rewards are not included.
+func (cs *chainState) Balance(address string) amount.Amount {
+	return cs.balances[address]
+}
+
+// Immature returns the summed coinbase rewards of the address that are still
+// locked.
+func (cs *chainState) Immature(address string) (amount.Amount, error) {
+	var locked amount.Amount
+	var err error
+	for _, r := range cs.immature {
+		if r.address != address {
+			continue
+		}
+		if locked, err = locked.Add(r.value); err != nil {
+			return 0, err
+		}
+	}
+	return locked, nil
+}
+
+// Known reports whether the address has appeared on the chain.
+func (cs *chainState) Known(address string) bool {
+	if _, ok := cs.balances[address]; ok {
+		return true
+	}
+	for _, r := range cs.immature {
+		if r.address == address {
+			return true
+		}
+	}
+	return false
+}
+
+// Nonce returns the next nonce expected from the address.
+func (cs *chainState) Nonce(address string) uint64 {
+	return cs.nonces[address]
+}
+
+// Supply returns the total amount minted so far.
+func (cs *chainState) Supply() amount.Amount {
+	return cs.supply
+}
+
+func (cs *chainState) copy() *chainState {
+	c := newChainState(cs.chainID, cs.params)
+	for addr, balance := range cs.balances {
+		c.balances[addr] = balance
+	}
+	for addr, nonce := range cs.nonces {
+		c.nonces[addr] = nonce
+	}
+	c.immature = append([]*lockedReward(nil), cs.immature...)
+	c.supply = cs.supply
+	return c
+}
+
+// applyTransfer checks a user transfer against the current state and, if it
+// is valid, debits value plus fee from the sender and credits the value to
+// the recipient. The fee is credited by the block's coinbase.
+func (cs *chainState) applyTransfer(t *Transaction) error {
+	if t.value == 0 {
+		return fmt.Errorf("zero value")
+	}
+	if t.senderPublicKey == nil || t.signature == nil {
+		return fmt.Errorf("missing sender public key or signature")
+	}
+	if !address.MatchesPublicKey(t.senderBlockchainAddress, t.senderPublicKey) {
+		return fmt.Errorf("sender address %s does not belong to the signing public key", t.senderBlockchainAddress)
+	}
+	if t.chainID != cs.chainID {
+		return fmt.Errorf("signed for chain %q, expected %q", t.chainID, cs.chainID)
+	}
+	if !t.VerifySignature() {
+		return fmt.Errorf("invalid signature by %s", t.senderBlockchainAddress)
+	}
+	if expected := cs.nonces[t.senderBlockchainAddress]; t.nonce != expected {
+		return fmt.Errorf("%s uses nonce %d, expected %d", t.senderBlockchainAddress, t.nonce, expected)
+	}
+	cost, err := t.Cost()
+	if err != nil {
+		return fmt.Errorf("cost of transfer: %v", err)
+	}
+	balance := cs.balances[t.senderBlockchainAddress]
+	if balance < cost {
+		return fmt.Errorf("%s spends %s but only has %s", t.senderBlockchainAddress, cost, balance)
+	}
+	cs.balances[t.senderBlockchainAddress] = balance - cost
+	if err := cs.credit(t.recipientBlockchainAddress, t.value); err != nil {
+		cs.balances[t.senderBlockchainAddress] = balance
+		return err
+	}
+	cs.nonces[t.senderBlockchainAddress]++
+	return nil
+}
+
+// credit adds value to the balance of address.
+func (cs *chainState) credit(address string, value amount.Amount) error {
+	balance, err := cs.balances[address].Add(value)
+	if err != nil {
+		return fmt.Errorf("crediting %s: %v", address, err)
+	}
+	cs.balances[address] = balance
+	return nil
+}
+
+// applyBlock validates every transaction of the block against the state and
+// applies them. Apart from the genesis block, which may only mint coins,
+// every block must fit the size limit and carry exactly one coinbase paying
+// the subsidy scheduled at its height plus the block's fees. Nothing may be
+// minted beyond the maximum supply. Coinbase rewards stay locked for
+// CoinbaseMaturity blocks; genesis allocations are spendable at once.
+func (cs *chainState) applyBlock(b *Block) error {
+	if size := transactionsSize(b.transactions); size > cs.params.MaxBlockSize {
+		return blockError(b.height, "transactions take %d bytes, limit is %d", size, cs.params.MaxBlockSize)
+	}
+
+	fees, err := totalFees(b.transactions)
+	if err != nil {
+		return blockError(b.height, "fees: %v", err)
+	}
+	subsidy := cs.params.BlockSubsidy(b.height, cs.supply)
+	reward, err := subsidy.Add(fees)
+	if err != nil {
+		return blockError(b.height, "coinbase: %v", err)
+	}
+
+	coinbases := 0
+	for i, t := range b.transactions {
+		switch {
+		case t.IsRegistration():
+			if err := cs.credit(t.recipientBlockchainAddress, 0); err != nil {
+				return txError(b.height, i, "%v", err)
+			}
+		case t.IsCoinbase():
+			coinbases++
+			if b.height > 0 && t.nonce != b.height {
+				return txError(b.height, i, "coinbase nonce %d does not match block height", t.nonce)
+			}
+			if b.height > 0 && t.value != reward {
+				return txError(b.height, i, "coinbase pays %s, expected %s", t.value, reward)
+			}
+			minted := subsidy
+			if b.height == 0 {
+				minted = t.value
+			}
+			supply, err := cs.supply.Add(minted)
+			if err != nil || supply > cs.params.MaxSupply {
+				return txError(b.height, i, "coinbase mints %s beyond the maximum supply of %s", minted, cs.params.MaxSupply)
+			}
+			if b.height == 0 {
+				if err := cs.credit(t.recipientBlockchainAddress, t.value); err != nil {
+					return txError(b.height, i, "%v", err)
+				}
+			} else {
+				cs.immature = append(cs.immature, &lockedReward{
+					address: t.recipientBlockchainAddress,
+					value:   t.value,
+					unlocks: b.height + cs.params.CoinbaseMaturity,
+				})
+			}
+			cs.supply = supply
+		default:
+			if b.height == 0 {
+				return txError(b.height, i, "genesis block contains a transfer")
+			}
+			if err := cs.applyTransfer(t); err != nil {
+				return txError(b.height, i, "%v", err)
+			}
+		}
+	}
+	if b.height > 0 && coinbases != 1 {
+		return blockError(b.height, "has %d coinbase transactions, expected 1", coinbases)
+	}
+	if err := cs.unlockRewards(b.height + 1); err != nil {
+		return blockError(b.height, "%v", err)
+	}
+	return nil
+}
+
+// unlockRewards makes the rewards that the block at height may spend part of
+// the balances.
+func (cs *chainState) unlockRewards(height uint64) error {
+	locked := cs.immature[:0:0]
+	for _, r := range cs.immature {
+		if r.unlocks > height {
+			locked = append(locked, r)
+			continue
+		}
+		if err := cs.credit(r.address, r.value); err != nil {
+			return err
+		}
+	}
+	cs.immature = locked
+	return nil
+}
+
+// transactionsSize sums the encoded size of the transactions.
+func transactionsSize(transactions []*Transaction) int {
+	size := 0
+	for _, t := range transactions {
+		size += t.Size()
+	}
+	return size
+}
+
+// totalFees sums the fees of every transfer in the transactions.
+func totalFees(transactions []*Transaction) (amount.Amount, error) {
+	var fees amount.Amount
+	var err error
+	for _, t := range transactions {
+		if t.senderBlockchainAddress == MINING_SENDER {
+			continue
+		}
+		if fees, err = fees.Add(t.fee); err != nil {
+			return 0, err
+		}
+	}
+	return fees, nil
+}
+
+// replayChain validates and applies every block of the chain against the
+// rules of the network defined by genesis.
+func replayChain(chain []*Block, genesis *Genesis) (*chainState, error) {
+	cs := newChainState(genesis.ChainID, genesis.Params)
+	for _, b := range chain {
+		if err := cs.applyBlock(b); err != nil {
+			return nil, err
+		}
+	}
+	return cs, nil
+}
+
+// refreshState rebuilds the cached tip state by replaying the whole chain.
+// The cached state is kept if the chain does not replay.
+func (bc *Blockchain) refreshState() error {
+	state, err := replayChain(bc.chain, bc.genesis)
+	if err != nil {
+		return fmt.Errorf("failed to replay chain: %v", err)
+	}
+	bc.state = state
+	return nil
+}
+
+// applyToState applies a block extending the chain to a copy of the cached
+// tip state, and only swaps the copy in if the whole block applied.
+func (bc *Blockchain) applyToState(b *Block) error {
+	if bc.state == nil {
+		return fmt.Errorf("current chain state is unavailable")
+	}
+	next := bc.state.copy()
+	if err := next.applyBlock(b); err != nil {
+		return err
+	}
+	bc.state = next
+	return nil
+}
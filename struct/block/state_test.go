@@ -0,0 +1,270 @@
+package block
+
+import (
+	"block/struct/amount"
+	"block/struct/utils"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// testState is the state after the genesis block of a network funding one
+// key with 10 coins.
+type testState struct {
+	genesis *Genesis
+	key     *ecdsa.PrivateKey
+	sender  string
+	state   *chainState
+}
+
+func newTestState(t *testing.T) *testState {
+	t.Helper()
+	key, sender := newTestKey(t)
+	g := testGenesis(sender)
+	state, err := replayChain([]*Block{g.Block()}, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testState{genesis: g, key: key, sender: sender, state: state}
+}
+
+// transfer signs a transfer by the funded key.
+func (s *testState) transfer(t *testing.T, recipient string, value amount.Amount, fee amount.Amount, nonce uint64) *Transaction {
+	t.Helper()
+	return signedTransfer(t, s.key, s.genesis.ChainID, recipient, value, fee, nonce)
+}
+
+// reward returns what the coinbase of a block at height carrying the
+// transfers must pay.
+func (s *testState) reward(t *testing.T, height uint64, transfers ...*Transaction) amount.Amount {
+	t.Helper()
+	fees, err := totalFees(transfers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reward, err := s.genesis.Params.BlockSubsidy(height, s.state.Supply()).Add(fees)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reward
+}
+
+// apply applies a block at height holding the transactions to a copy of the
+// state, keeping the copy only if the block applies.
+func (s *testState) apply(height uint64, transactions ...*Transaction) error {
+	next := s.state.copy()
+	if err := next.applyBlock(&Block{height: height, transactions: transactions}); err != nil {
+		return err
+	}
+	s.state = next
+	return nil
+}
+
+// rejects checks that the block is refused because of the transaction at
+// index, or the block as a whole for index -1.
+func (s *testState) rejects(t *testing.T, name string, index int, height uint64, transactions ...*Transaction) {
+	t.Helper()
+	err := s.apply(height, transactions...)
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Errorf("%s: applyBlock error = %v, want a *ValidationError", name, err)
+		return
+	}
+	if verr.Height != height || verr.TxIndex != index {
+		t.Errorf("%s: rejected at block %d, transaction %d (%v), want block %d, transaction %d",
+			name, verr.Height, verr.TxIndex, err, height, index)
+	}
+}
+
+func TestApplyBlock(t *testing.T) {
+	s := newTestState(t)
+	miner, recipient := newTestAddress(t), newTestAddress(t)
+	if s.state.Balance(s.sender) != amount.MustFromCoins(10) {
+		t.Fatalf("genesis allocation gives %s, want 10 coins", s.state.Balance(s.sender))
+	}
+
+	tx := s.transfer(t, recipient, amount.MustFromCoins(3), 500, 0)
+	if err := s.apply(1, tx, coinbase(miner, s.reward(t, 1, tx), 1)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.state.Balance(s.sender), amount.MustFromCoins(7)-500; got != want {
+		t.Errorf("sender balance = %s, want %s", got, want)
+	}
+	if got := s.state.Balance(recipient); got != amount.MustFromCoins(3) {
+		t.Errorf("recipient balance = %s, want 3 coins", got)
+	}
+	if got := s.state.Nonce(s.sender); got != 1 {
+		t.Errorf("sender nonce = %d, want 1", got)
+	}
+	if got, _ := s.state.Immature(miner); got != s.genesis.Params.Subsidy(1)+500 {
+		t.Errorf("miner has %s locked, want the subsidy plus 500", got)
+	}
+	if s.state.Supply() != amount.MustFromCoins(10)+s.genesis.Params.Subsidy(1) {
+		t.Errorf("supply = %s, want the allocation plus one subsidy", s.state.Supply())
+	}
+}
+
+func TestApplyBlockRejects(t *testing.T) {
+	s := newTestState(t)
+	miner, recipient := newTestAddress(t), newTestAddress(t)
+	other, _ := newTestKey(t)
+
+	valid := func() *Transaction { return s.transfer(t, recipient, 1000, 10, 0) }
+	cb := func(transfers ...*Transaction) *Transaction { return coinbase(miner, s.reward(t, 1, transfers...), 1) }
+
+	tampered := valid()
+	tampered.value = 2000
+	tampered.seal()
+	s.rejects(t, "tampered value", 0, 1, tampered, cb(tampered))
+
+	forged := valid()
+	forged.signature = signedTransfer(t, other, s.genesis.ChainID, recipient, 1000, 10, 0).signature
+	forged.seal()
+	s.rejects(t, "signature by another key", 0, 1, forged, cb(forged))
+
+	stolen := signedTransfer(t, other, s.genesis.ChainID, recipient, 1000, 10, 0)
+	stolen.senderBlockchainAddress = s.sender
+	stolen.seal()
+	s.rejects(t, "sender of another key", 0, 1, stolen, cb(stolen))
+
+	highS := valid()
+	highS.signature = &utils.Signature{R: highS.signature.R, S: new(big.Int).Sub(s.key.Curve.Params().N, highS.signature.S)}
+	highS.seal()
+	s.rejects(t, "high-S signature", 0, 1, highS, cb(highS))
+
+	unsigned := valid()
+	unsigned.signature = nil
+	unsigned.seal()
+	s.rejects(t, "missing signature", 0, 1, unsigned, cb(unsigned))
+
+	foreign := signedTransfer(t, s.key, "another-chain", recipient, 1000, 10, 0)
+	s.rejects(t, "other chain", 0, 1, foreign, cb(foreign))
+
+	overdraft := s.transfer(t, recipient, amount.MustFromCoins(10), 1, 0)
+	s.rejects(t, "overdraft", 0, 1, overdraft, cb(overdraft))
+
+	tx := valid()
+	s.rejects(t, "no coinbase", -1, 1, tx)
+	s.rejects(t, "second coinbase", -1, 1, tx, cb(tx), cb(tx))
+	s.rejects(t, "coinbase paying one unit more", 1, 1, tx, coinbase(miner, s.reward(t, 1, tx)+1, 1))
+	s.rejects(t, "coinbase leaving out the fees", 1, 1, tx, coinbase(miner, s.reward(t, 1), 1))
+	s.rejects(t, "coinbase nonce", 1, 1, tx, coinbase(miner, s.reward(t, 1, tx), 2))
+	s.rejects(t, "transfer in the genesis block", 0, 0, tx)
+
+	params := *s.genesis.Params
+	params.MaxBlockSize = tx.Size()
+	s.state.params = &params
+	s.rejects(t, "block over the size limit", -1, 1, tx, cb(tx))
+	s.state.params = s.genesis.Params
+
+	// None of the rejected blocks left a trace
+	if s.state.Balance(s.sender) != amount.MustFromCoins(10) || s.state.Nonce(s.sender) != 0 || s.state.Known(miner) {
+		t.Fatal("a rejected block changed the state")
+	}
+	if err := s.apply(1, tx, cb(tx)); err != nil {
+		t.Fatalf("valid block is rejected: %v", err)
+	}
+}
+
+func TestApplyBlockNonces(t *testing.T) {
+	s := newTestState(t)
+	miner, recipient := newTestAddress(t), newTestAddress(t)
+
+	first := s.transfer(t, recipient, 1000, 0, 0)
+	second := s.transfer(t, recipient, 1000, 0, 1)
+	s.rejects(t, "gap before the first nonce", 0, 1, second, coinbase(miner, s.reward(t, 1), 1))
+	s.rejects(t, "nonces out of order", 0, 1, second, first, coinbase(miner, s.reward(t, 1), 1))
+	s.rejects(t, "nonce repeated in a block", 1, 1, first, first, coinbase(miner, s.reward(t, 1), 1))
+
+	// Both nonces in one block, in order
+	if err := s.apply(1, first, second, coinbase(miner, s.reward(t, 1), 1)); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.state.Nonce(s.sender); got != 2 {
+		t.Fatalf("nonce after two transfers = %d, want 2", got)
+	}
+
+	// A confirmed transfer cannot be replayed, and nonce 3 would leave a gap
+	s.rejects(t, "replayed transfer", 0, 2, first, coinbase(miner, s.reward(t, 2), 2))
+	skipped := s.transfer(t, recipient, 1000, 0, 3)
+	s.rejects(t, "skipped nonce", 0, 2, skipped, coinbase(miner, s.reward(t, 2), 2))
+
+	next := s.transfer(t, recipient, 1000, 0, 2)
+	if err := s.apply(2, next, coinbase(miner, s.reward(t, 2), 2)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAddTransactionNonces(t *testing.T) {
+	key, sender := newTestKey(t)
+	g := testGenesis(sender)
+	bc := newTestBlockchain(t, g)
+	recipient := newTestAddress(t)
+
+	submit(t, bc, signedTransfer(t, key, g.ChainID, recipient, 1000, 0, 0))
+	for _, nonce := range []uint64{0, 2} {
+		tx := signedTransfer(t, key, g.ChainID, recipient, 1000, 0, nonce)
+		if _, err := bc.AddTransaction(tx.chainID, sender, recipient, "", 1000, 0, nonce, &key.PublicKey, tx.signature); err == nil {
+			t.Errorf("AddTransaction accepted nonce %d after nonce 0", nonce)
+		}
+	}
+	if got := bc.NextNonce(sender); got != 1 {
+		t.Fatalf("NextNonce = %d, want 1", got)
+	}
+
+	// Once mined, nonce 0 stays used
+	mine(t, bc, newTestAddress(t), 1)
+	tx := signedTransfer(t, key, g.ChainID, recipient, 1000, 0, 0)
+	if _, err := bc.AddTransaction(tx.chainID, sender, recipient, "", 1000, 0, 0, &key.PublicKey, tx.signature); err == nil {
+		t.Error("AddTransaction accepted a confirmed nonce")
+	}
+	submit(t, bc, signedTransfer(t, key, g.ChainID, recipient, 1000, 0, 1))
+}
+
+func TestCoinbaseMaturity(t *testing.T) {
+	s := newTestState(t)
+	minerKey, miner := newTestKey(t)
+	other, recipient := newTestAddress(t), newTestAddress(t)
+	maturity := s.genesis.Params.CoinbaseMaturity
+	reward := s.reward(t, 1)
+
+	if err := s.apply(1, coinbase(miner, reward, 1)); err != nil {
+		t.Fatal(err)
+	}
+	spend := signedTransfer(t, minerKey, s.genesis.ChainID, recipient, reward, 0, 0)
+	for height := uint64(2); height <= maturity; height++ {
+		if locked, _ := s.state.Immature(miner); locked != reward || s.state.Balance(miner) != 0 {
+			t.Fatalf("before block %d the miner has %s locked and %s spendable, want %s locked",
+				height, locked, s.state.Balance(miner), reward)
+		}
+		s.rejects(t, "spending an immature reward", 0, height, spend, coinbase(other, s.reward(t, height), height))
+		if err := s.apply(height, coinbase(other, s.reward(t, height), height)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The block at height 1+maturity may spend it
+	height := 1 + maturity
+	if locked, _ := s.state.Immature(miner); locked != 0 || s.state.Balance(miner) != reward {
+		t.Fatalf("before block %d the miner has %s locked and %s spendable, want all %s spendable",
+			height, locked, s.state.Balance(miner), reward)
+	}
+	if err := s.apply(height, spend, coinbase(other, s.reward(t, height), height)); err != nil {
+		t.Fatalf("spending a mature reward: %v", err)
+	}
+	if s.state.Balance(recipient) != reward {
+		t.Fatalf("recipient has %s, want %s", s.state.Balance(recipient), reward)
+	}
+}
+
+func TestGenesisAllocationIsSpendable(t *testing.T) {
+	s := newTestState(t)
+	if locked, _ := s.state.Immature(s.sender); locked != 0 {
+		t.Fatalf("genesis allocation has %s locked", locked)
+	}
+	tx := s.transfer(t, newTestAddress(t), amount.MustFromCoins(9), 0, 0)
+	if err := s.apply(1, tx, coinbase(newTestAddress(t), s.reward(t, 1), 1)); err != nil {
+		t.Fatalf("spending the genesis allocation in block 1: %v", err)
+	}
+}
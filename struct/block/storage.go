@@ -1,61 +1,214 @@
 package block
 
 import (
+	"block/struct/config"
+	"block/struct/storage"
 	"encoding/json"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
+	"sync"
 )
 
-const blockchainFile = "blockchain.json"
+// Files of a data directory. The snapshot holds the whole chain as of the
+// last checkpoint; the journal holds the blocks appended since.
+const (
+	SNAPSHOT_FILE = "blockchain.json"
+	JOURNAL_FILE  = "blockchain.journal"
+	// JOURNAL_CHECKPOINT_BLOCKS is how many blocks the journal collects
+	// before they are folded into a new snapshot.
+	JOURNAL_CHECKPOINT_BLOCKS = 100
+)
 
-// SaveBlockchain saves the blockchain to a file
-func (bc *Blockchain) SaveBlockchain() error {
-	// Create data directory if it doesn't exist
-	if err := os.MkdirAll("data", 0755); err != nil {
-		return fmt.Errorf("failed to create data directory: %v", err)
+// journalRecord is a block appended to the chain.
+type journalRecord struct {
+	Height uint64 `json:"height"`
+	Block  *Block `json:"block"`
+}
+
+// store persists a chain in a locked data directory.
+type store struct {
+	dir     string
+	lock    *storage.Lock
+	journal *storage.Journal
+	mux     sync.Mutex
+	// height and tipHash describe the persisted chain, journaled counts the
+	// blocks in the journal.
+	height    uint64
+	tipHash   string
+	journaled int
+}
+
+func openStore(dir string) (*store, error) {
+	lock, err := storage.LockDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	journal, err := storage.OpenJournal(filepath.Join(dir, JOURNAL_FILE))
+	if err != nil {
+		lock.Release()
+		return nil, fmt.Errorf("failed to open journal: %v", err)
 	}
+	return &store{dir: dir, lock: lock, journal: journal}, nil
+}
 
-	// Marshal blockchain to JSON
-	data, err := json.Marshal(bc)
+func (s *store) close() {
+	s.journal.Close()
+	s.lock.Release()
+}
+
+// load reads the snapshot and replays the journal on top of it. Journal
+// records the snapshot already covers are skipped and a torn tail is cut
+// off; an intact record that does not extend the chain is an error, so the
+// blocks after it are kept for inspection.
+func (s *store) load() ([]*Block, error) {
+	var chain []*Block
+	data, err := os.ReadFile(filepath.Join(s.dir, SNAPSHOT_FILE))
+	if err == nil {
+		var bc Blockchain
+		if err := json.Unmarshal(data, &bc); err != nil {
+			return nil, fmt.Errorf("failed to parse snapshot: %v", err)
+		}
+		chain = bc.chain
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read snapshot: %v", err)
+	}
+
+	journaled := 0
+	dropped, err := s.journal.Replay(func(record []byte) error {
+		var r journalRecord
+		if err := json.Unmarshal(record, &r); err != nil {
+			return err
+		}
+		switch {
+		case r.Block == nil:
+			return fmt.Errorf("record without a block")
+		case r.Height < uint64(len(chain)):
+			return nil
+		case r.Height > uint64(len(chain)) || len(chain) == 0:
+			return fmt.Errorf("block %d leaves a gap after %d blocks", r.Height, len(chain))
+		case r.Block.GetPrevHash() != chain[len(chain)-1].GetHash():
+			return fmt.Errorf("block %d does not extend the chain", r.Height)
+		}
+		chain = append(chain, r.Block)
+		journaled++
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to marshal blockchain: %v", err)
+		return nil, fmt.Errorf("failed to replay journal: %v", err)
+	}
+	if dropped > 0 {
+		log.Printf("WARNING: Dropped %d bytes from the end of the journal, recovered %d blocks", dropped, len(chain))
 	}
 
-	// Write to file
-	filePath := filepath.Join("data", blockchainFile)
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write blockchain file: %v", err)
+	if len(chain) > 0 {
+		s.height = uint64(len(chain))
+		s.tipHash = chain[len(chain)-1].GetHash()
+		s.journaled = journaled
 	}
+	return chain, nil
+}
 
+// save persists chain. Blocks that extend the persisted chain are appended to
+// the journal; anything else, such as a reorg or a reset, and every
+// JOURNAL_CHECKPOINT_BLOCKS blocks, writes a new snapshot.
+func (s *store) save(chain []*Block) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	extends := s.height > 0 && uint64(len(chain)) >= s.height &&
+		chain[s.height-1].GetHash() == s.tipHash
+	added := len(chain) - int(s.height)
+	if !extends || s.journaled+added > JOURNAL_CHECKPOINT_BLOCKS {
+		return s.checkpoint(chain)
+	}
+
+	for _, b := range chain[s.height:] {
+		record, err := json.Marshal(journalRecord{Height: b.GetHeight(), Block: b})
+		if err != nil {
+			return fmt.Errorf("failed to marshal block %d: %v", b.GetHeight(), err)
+		}
+		if err := s.journal.Append(record); err != nil {
+			return fmt.Errorf("failed to journal block %d: %v", b.GetHeight(), err)
+		}
+		s.height++
+		s.tipHash = b.GetHash()
+		s.journaled++
+	}
 	return nil
 }
 
-// LoadBlockchain loads the blockchain from a file
-func LoadBlockchain(port uint16) *Blockchain {
-	filePath := filepath.Join("data", blockchainFile)
+// checkpoint replaces the snapshot with chain and empties the journal. A
+// crash in between leaves journal records that load skips.
+func (s *store) checkpoint(chain []*Block) error {
+	data, err := json.Marshal(&Blockchain{chain: chain})
+	if err != nil {
+		return fmt.Errorf("failed to marshal blockchain: %v", err)
+	}
+	if err := storage.WriteFileAtomic(filepath.Join(s.dir, SNAPSHOT_FILE), data, 0644); err != nil {
+		return fmt.Errorf("failed to write blockchain file: %v", err)
+	}
+	if err := s.journal.Reset(); err != nil {
+		return fmt.Errorf("failed to reset journal: %v", err)
+	}
+	s.height = uint64(len(chain))
+	s.tipHash = ""
+	if len(chain) > 0 {
+		s.tipHash = chain[len(chain)-1].GetHash()
+	}
+	s.journaled = 0
+	return nil
+}
 
-	// Check if file exists
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+// SaveBlockchain persists the chain to the data directory. Chains created by
+// NewBlockchain have none and are kept in memory only.
+func (bc *Blockchain) SaveBlockchain() error {
+	if bc.store == nil {
 		return nil
 	}
+	return bc.store.save(bc.chain)
+}
 
-	// Read file
-	data, err := os.ReadFile(filePath)
+// OpenBlockchain opens the chain kept in the configured data directory,
+// locking the directory for this process, or starts one from the genesis
+// block when the directory is empty. Blocks mined from then on pay
+// blockchainAddress.
+func OpenBlockchain(blockchainAddress string, cfg *config.Config, genesis *Genesis) (*Blockchain, error) {
+	s, err := openStore(cfg.DataDir)
 	if err != nil {
-		fmt.Printf("Error reading blockchain file: %v\n", err)
-		return nil
+		return nil, err
 	}
-
-	// Unmarshal JSON
-	var bc Blockchain
-	if err := json.Unmarshal(data, &bc); err != nil {
-		fmt.Printf("Error unmarshaling blockchain: %v\n", err)
-		return nil
+	chain, err := s.load()
+	if err != nil {
+		s.close()
+		return nil, err
 	}
 
-	// Set port
-	bc.port = port
+	bc := new(Blockchain)
+	bc.blockchainAddress = blockchainAddress
+	bc.initialize(cfg, genesis)
+	bc.store = s
+	if len(chain) == 0 {
+		bc.resetToGenesis()
+		log.Printf("Created new blockchain in %s", cfg.DataDir)
+		if err := bc.SaveBlockchain(); err != nil {
+			s.close()
+			return nil, err
+		}
+		return bc, nil
+	}
 
-	return &bc
+	// Refuse to run on a chain that does not replay cleanly
+	if err := bc.VerifyChain(chain); err != nil {
+		s.close()
+		return nil, fmt.Errorf("blockchain in %s is invalid: %v", cfg.DataDir, err)
+	}
+	bc.chain = chain
+	if err := bc.refreshState(); err != nil {
+		s.close()
+		return nil, err
+	}
+	log.Printf("Loaded blockchain of %d blocks from %s", len(chain), cfg.DataDir)
+	return bc, nil
 }
@@ -0,0 +1,176 @@
+package block
+
+import (
+	"block/struct/config"
+	"block/struct/storage"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func openTestBlockchain(t *testing.T, dir string, g *Genesis) *Blockchain {
+	t.Helper()
+	cfg := config.Default()
+	cfg.DataDir = dir
+	bc, err := OpenBlockchain(newTestAddress(t), cfg, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bc
+}
+
+func journalSize(t *testing.T, dir string) int64 {
+	t.Helper()
+	info, err := os.Stat(filepath.Join(dir, JOURNAL_FILE))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Size()
+}
+
+func saveAndClose(t *testing.T, bc *Blockchain) {
+	t.Helper()
+	if err := bc.SaveBlockchain(); err != nil {
+		t.Fatal(err)
+	}
+	bc.store.close()
+}
+
+func TestStoreJournalAndCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	g := testGenesis()
+	miner := newTestAddress(t)
+
+	bc := openTestBlockchain(t, dir, g)
+	for i := 0; i < 3; i++ {
+		mine(t, bc, miner, 1)
+		if err := bc.SaveBlockchain(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if bc.store.journaled != 3 || journalSize(t, dir) == 0 {
+		t.Fatalf("journal holds %d blocks, want the 3 mined since the snapshot", bc.store.journaled)
+	}
+	tip := bc.LastBlock().GetHash()
+	bc.store.close()
+
+	// The snapshot holds the genesis block, the journal the rest
+	bc = openTestBlockchain(t, dir, g)
+	if len(bc.chain) != 4 || bc.LastBlock().GetHash() != tip {
+		t.Fatalf("reopened chain has %d blocks ending in %s, want 4 ending in %s", len(bc.chain), bc.LastBlock().GetHash(), tip)
+	}
+
+	// Enough blocks fold the journal into a new snapshot
+	for bc.store.journaled > 0 {
+		mine(t, bc, miner, 1)
+		if err := bc.SaveBlockchain(); err != nil {
+			t.Fatal(err)
+		}
+		if len(bc.chain) > JOURNAL_CHECKPOINT_BLOCKS+10 {
+			t.Fatal("journal was never checkpointed")
+		}
+	}
+	if journalSize(t, dir) != 0 {
+		t.Fatal("journal is not empty after a checkpoint")
+	}
+	mine(t, bc, miner, 1)
+	height := len(bc.chain)
+	saveAndClose(t, bc)
+
+	bc = openTestBlockchain(t, dir, g)
+	defer bc.store.close()
+	if len(bc.chain) != height || bc.store.journaled != 1 {
+		t.Fatalf("reopened chain has %d blocks with %d journaled, want %d with 1", len(bc.chain), bc.store.journaled, height)
+	}
+}
+
+func TestStoreReorgWritesSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	g := testGenesis()
+	bc := openTestBlockchain(t, dir, g)
+	defer bc.store.close()
+
+	mine(t, bc, newTestAddress(t), 2)
+	if err := bc.SaveBlockchain(); err != nil {
+		t.Fatal(err)
+	}
+	// A chain that does not extend the persisted tip replaces the snapshot
+	bc.chain = bc.chain[:2]
+	if err := bc.SaveBlockchain(); err != nil {
+		t.Fatal(err)
+	}
+	if bc.store.journaled != 0 || bc.store.height != 2 || journalSize(t, dir) != 0 {
+		t.Fatalf("store at height %d with %d journaled, want a snapshot of 2 blocks", bc.store.height, bc.store.journaled)
+	}
+}
+
+func TestStoreTornJournal(t *testing.T) {
+	dir := t.TempDir()
+	g := testGenesis()
+	bc := openTestBlockchain(t, dir, g)
+	mine(t, bc, newTestAddress(t), 2)
+	saveAndClose(t, bc)
+
+	// Cut the second journaled block short
+	path := filepath.Join(dir, JOURNAL_FILE)
+	if err := os.Truncate(path, journalSize(t, dir)-10); err != nil {
+		t.Fatal(err)
+	}
+	bc = openTestBlockchain(t, dir, g)
+	defer bc.store.close()
+	if len(bc.chain) != 2 || bc.store.journaled != 1 {
+		t.Fatalf("recovered chain has %d blocks with %d journaled, want 2 with 1", len(bc.chain), bc.store.journaled)
+	}
+}
+
+func TestStoreRejectsIntactBadRecord(t *testing.T) {
+	dir := t.TempDir()
+	g := testGenesis()
+	bc := openTestBlockchain(t, dir, g)
+	mine(t, bc, newTestAddress(t), 2)
+	saveAndClose(t, bc)
+
+	// Append an intact record for a block that leaves a gap
+	j, err := storage.OpenJournal(filepath.Join(dir, JOURNAL_FILE))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := j.Replay(func([]byte) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	record, err := json.Marshal(journalRecord{Height: 7, Block: bc.chain[2]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Append(record); err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+	size := journalSize(t, dir)
+
+	cfg := config.Default()
+	cfg.DataDir = dir
+	if _, err := OpenBlockchain(newTestAddress(t), cfg, g); err == nil {
+		t.Fatal("OpenBlockchain accepted a journal with a gap")
+	}
+	if got := journalSize(t, dir); got != size {
+		t.Fatalf("journal is %d bytes after a rejected record, want %d kept", got, size)
+	}
+}
+
+func TestStoreLocked(t *testing.T) {
+	dir := t.TempDir()
+	g := testGenesis()
+	bc := openTestBlockchain(t, dir, g)
+
+	cfg := config.Default()
+	cfg.DataDir = dir
+	if _, err := OpenBlockchain(newTestAddress(t), cfg, g); !errors.Is(err, storage.ErrLocked) {
+		t.Fatalf("second OpenBlockchain error = %v, want storage.ErrLocked", err)
+	}
+	bc.store.close()
+	bc = openTestBlockchain(t, dir, g)
+	bc.store.close()
+}
@@ -0,0 +1,33 @@
+package block
+
+import "block/struct/amount"
+
+// SupplyInfo describes the emission schedule as seen from the chain tip.
+type SupplyInfo struct {
+	Height            uint64        `json:"height"`
+	Circulating       amount.Amount `json:"circulatingSupply"`
+	MaxSupply         amount.Amount `json:"maxSupply"`
+	CurrentSubsidy    amount.Amount `json:"currentSubsidy"`
+	HalvingInterval   uint64        `json:"halvingInterval"`
+	NextHalvingHeight uint64        `json:"nextHalvingHeight,omitempty"`
+}
+
+// Supply reports the amount minted up to the tip and the subsidy the next
+// block will mint.
+func (bc *Blockchain) Supply() *SupplyInfo {
+	bc.mux.Lock()
+	defer bc.mux.Unlock()
+
+	height := bc.LastBlock().GetHeight()
+	info := &SupplyInfo{
+		Height:            height,
+		MaxSupply:         bc.params.MaxSupply,
+		HalvingInterval:   bc.params.HalvingInterval,
+		NextHalvingHeight: bc.params.NextHalvingHeight(height + 1),
+	}
+	if bc.state != nil {
+		info.Circulating = bc.state.Supply()
+		info.CurrentSubsidy = bc.params.BlockSubsidy(height+1, info.Circulating)
+	}
+	return info
+}
@@ -1,11 +1,17 @@
 package block
 
 import (
+	"block/struct/address"
+	"block/struct/amount"
+	"block/struct/mempool"
+	"block/struct/signing"
 	"block/struct/utils"
 	"bytes"
 	"crypto/ecdsa"
 	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -13,129 +19,289 @@ import (
 )
 
 type Transaction struct {
+	chainID                    string
 	message                    string
 	recipientBlockchainAddress string
 	senderBlockchainAddress    string
-	value                      float32
+	value                      amount.Amount
+	fee                        amount.Amount
+	nonce                      uint64
+	senderPublicKey            *ecdsa.PublicKey
+	signature                  *utils.Signature
+	// encoded is the JSON encoding, computed once when the transaction is
+	// created since its fields never change afterwards.
+	encoded []byte
 }
 
 type TransactionRequest struct {
-	Message                    *string  `json:"message"`
-	RecipientBlockchainAddress *string  `json:"recipientBlockchainAddress"`
-	SenderBlockchainAddress    *string  `json:"senderBlockchainAddress"`
-	SenderPublicKey            *string  `json:"senderPublicKey"`
-	Signature                  *string  `json:"signature"`
-	Value                      *float32 `json:"value"`
+	ChainID                    *string        `json:"chainId"`
+	Message                    *string        `json:"message"`
+	RecipientBlockchainAddress *string        `json:"recipientBlockchainAddress"`
+	SenderBlockchainAddress    *string        `json:"senderBlockchainAddress"`
+	SenderPublicKey            *string        `json:"senderPublicKey"`
+	Signature                  *string        `json:"signature"`
+	Value                      *amount.Amount `json:"value"`
+	Fee                        *amount.Amount `json:"fee"`
+	Nonce                      *uint64        `json:"nonce"`
 }
 
+// BalanceResponse splits an account's funds: Balance is the confirmed amount
+// that can be spent, Immature the coinbase rewards still locked, and
+// PendingIncoming and PendingOutgoing what pooled transactions will move.
 type BalanceResponse struct {
-	Balance float32 `json:"balance"`
-	Error   string  `json:"error"`
+	Balance         amount.Amount `json:"balance"`
+	Immature        amount.Amount `json:"immature"`
+	PendingIncoming amount.Amount `json:"pendingIncoming"`
+	PendingOutgoing amount.Amount `json:"pendingOutgoing"`
+	Error           string        `json:"error"`
 }
 
-func (bc *Blockchain) AddTransaction(sender string,
+// AddTransaction verifies a transaction's signature and admits it to the
+// pool, which checks its nonce and the sender's balance net of pending
+// spends. The sender address must belong to the signing key. An empty
+// chainID stands for the node's own chain; transactions signed for another
+// chain are rejected. It returns the transaction ID.
+func (bc *Blockchain) AddTransaction(chainID string,
+	sender string,
 	recipient string,
 	message string,
-	value float32,
+	value amount.Amount,
+	fee amount.Amount,
+	nonce uint64,
 	senderPublicKey *ecdsa.PublicKey,
-	s *utils.Signature) (bool, error) {
+	s *utils.Signature) (string, error) {
+
+	if chainID == "" {
+		chainID = bc.genesis.ChainID
+	}
+	if chainID != bc.genesis.ChainID {
+		return "", fmt.Errorf("ERROR: Transaction is signed for chain %q, this node runs chain %q", chainID, bc.genesis.ChainID)
+	}
+
+	if err := address.Validate(recipient); err != nil {
+		return "", fmt.Errorf("ERROR: Recipient: %w", err)
+	}
 
 	t := &Transaction{
+		chainID:                    chainID,
 		senderBlockchainAddress:    sender,
 		recipientBlockchainAddress: recipient,
 		message:                    message,
 		value:                      value,
+		fee:                        fee,
+		nonce:                      nonce,
+		senderPublicKey:            senderPublicKey,
+		signature:                  s,
 	}
+	t.seal()
 
 	if sender == MINING_SENDER {
-		bc.transactionPool = append(bc.transactionPool, t)
-		return true, nil
+		return "", fmt.Errorf("ERROR: Transactions from %s are created by miners only", MINING_SENDER)
 	}
 
-	if !bc.VerifyTransactionSignature(senderPublicKey, s, t) {
-		return false, fmt.Errorf("ERROR: Verify Transaction")
+	if value == 0 {
+		return "", fmt.Errorf("ERROR: Transaction value must be positive")
 	}
 
-	balance, err := bc.CalculateTotalBalance(sender)
-	if err != nil {
-		return false, fmt.Errorf("ERROR: CalculateTotalAmount: %v", err)
+	if senderPublicKey != nil && !address.MatchesPublicKey(sender, senderPublicKey) {
+		return "", fmt.Errorf("ERROR: Sender address %s is not derived from the signing public key, which belongs to %s",
+			sender, address.FromPublicKey(senderPublicKey))
 	}
 
-	if balance < value {
-		return false, fmt.Errorf("ERROR: Not enough balance in a wallet")
+	if s != nil && !signing.IsLowS(s) {
+		return "", fmt.Errorf("ERROR: Signature is not canonical: s must be at most half the curve order")
 	}
 
-	bc.transactionPool = append(bc.transactionPool, t)
-
-	if err := bc.SaveBlockchain(); err != nil {
-		log.Printf("ERROR: Failed to save blockchain after adding transaction: %v", err)
+	if !bc.VerifyTransactionSignature(senderPublicKey, s, t) {
+		return "", fmt.Errorf("ERROR: Verify Transaction: the signature must cover chain ID %q", chainID)
 	}
-	return true, nil
-}
 
-func (bc *Blockchain) ClearTransactionPool() {
-	bc.transactionPool = bc.transactionPool[:0]
+	bc.mux.Lock()
+	defer bc.mux.Unlock()
+
+	if err := bc.pool.Add(t, bc.state); err != nil {
+		if locked, _ := bc.state.Immature(sender); errors.Is(err, mempool.ErrInsufficientFunds) && locked > 0 {
+			return "", fmt.Errorf("ERROR: %v (%s more is locked in immature mining rewards)", err, locked)
+		}
+		return "", fmt.Errorf("ERROR: %v", err)
+	}
+	return t.ID(), nil
 }
 
-func (bc *Blockchain) CreateTransaction(sender string, recipient string, message string, value float32,
-	senderPublicKey *ecdsa.PublicKey, s *utils.Signature) (bool, error) {
+func (bc *Blockchain) CreateTransaction(chainID string, sender string, recipient string, message string, value amount.Amount,
+	fee amount.Amount, nonce uint64, senderPublicKey *ecdsa.PublicKey, s *utils.Signature) (string, error) {
 
-	isTransacted, err := bc.AddTransaction(sender, recipient, message, value, senderPublicKey, s)
+	id, err := bc.AddTransaction(chainID, sender, recipient, message, value, fee, nonce, senderPublicKey, s)
 
 	if err != nil {
 
 		log.Printf("ERROR: %v", err)
-		return false, err
-	}
-
-	if isTransacted {
-		for _, n := range bc.neighbors {
-			publicKeyStr := fmt.Sprintf("%064x%064x", senderPublicKey.X.Bytes(),
-				senderPublicKey.Y.Bytes())
-			signatureStr := s.String()
-			bt := &TransactionRequest{
-				&message, &publicKeyStr, &recipient, &sender, &signatureStr, &value}
-			m, _ := json.Marshal(bt)
-			buf := bytes.NewBuffer(m)
-			endpoint := fmt.Sprintf("%s/transactions", n)
-			client := &http.Client{}
-			req, _ := http.NewRequest("PUT", endpoint, buf)
-			resp, err := client.Do(req)
-			if err != nil {
-				log.Printf("ERROR: %v", err)
-				return false, err
-			}
-			log.Printf("%v", resp)
+		return "", err
+	}
+
+	for _, n := range bc.neighbors {
+		publicKeyStr := utils.PublicKeyString(senderPublicKey)
+		signatureStr := s.String()
+		chainID := bc.genesis.ChainID
+		bt := &TransactionRequest{
+			ChainID:                    &chainID,
+			Message:                    &message,
+			RecipientBlockchainAddress: &recipient,
+			SenderBlockchainAddress:    &sender,
+			SenderPublicKey:            &publicKeyStr,
+			Signature:                  &signatureStr,
+			Value:                      &value,
+			Fee:                        &fee,
+			Nonce:                      &nonce,
 		}
+		m, _ := json.Marshal(bt)
+		buf := bytes.NewBuffer(m)
+		endpoint := fmt.Sprintf("%s/transactions", n)
+		client := &http.Client{}
+		req, _ := http.NewRequest("PUT", endpoint, buf)
+		resp, err := client.Do(req)
+		if err != nil {
+			log.Printf("ERROR: %v", err)
+			return "", err
+		}
+		log.Printf("%v", resp)
 	}
 
-	return isTransacted, nil
+	return id, nil
 }
 
 func (bc *Blockchain) CopyTransactionPool() []*Transaction {
 	transactions := make([]*Transaction, 0)
-	for _, t := range bc.transactionPool {
-		transactions = append(transactions,
-			NewTransaction(
-				t.senderBlockchainAddress,
-				t.recipientBlockchainAddress,
-				t.message,
-				t.value))
+	for _, t := range bc.TransactionPool() {
+		c := *t
+		transactions = append(transactions, &c)
 	}
 	return transactions
 }
 
-func NewTransaction(sender string, recipient string, message string, value float32) *Transaction {
-	return &Transaction{
+func NewTransaction(sender string, recipient string, message string, value amount.Amount, fee amount.Amount, nonce uint64) *Transaction {
+	t := &Transaction{
 		senderBlockchainAddress:    sender,
 		recipientBlockchainAddress: recipient,
 		message:                    message,
 		value:                      value,
+		fee:                        fee,
+		nonce:                      nonce,
 	}
+	t.seal()
+	return t
+}
+
+// GetValue returns the amount transferred to the recipient.
+func (t *Transaction) GetValue() amount.Amount {
+	return t.value
 }
 
+// GetFee returns the fee the sender pays to the miner of the block.
+func (t *Transaction) GetFee() amount.Amount {
+	return t.fee
+}
+
+// Cost returns the total debited from the sender, value plus fee.
+func (t *Transaction) Cost() (amount.Amount, error) {
+	return t.value.Add(t.fee)
+}
+
+// Size returns the length in bytes of the transaction's JSON encoding, the
+// unit block size limits and fee rates are measured in.
+func (t *Transaction) Size() int {
+	return len(t.encoding())
+}
+
+// GetSender returns the sender's blockchain address.
+func (t *Transaction) GetSender() string {
+	return t.senderBlockchainAddress
+}
+
+// GetRecipient returns the recipient's blockchain address.
+func (t *Transaction) GetRecipient() string {
+	return t.recipientBlockchainAddress
+}
+
+// GetNonce returns the sender's sequence number carried by the transaction.
+func (t *Transaction) GetNonce() uint64 {
+	return t.nonce
+}
+
+// NextNonce returns the nonce the next transaction from the address must
+// carry: the confirmed sequence number plus the sender's pooled transactions.
+func (bc *Blockchain) NextNonce(address string) uint64 {
+	bc.mux.Lock()
+	defer bc.mux.Unlock()
+	return bc.state.Nonce(address) + uint64(bc.pool.PendingCount(address))
+}
+
+// IsCoinbase reports whether the transaction mints a mining reward.
+func (t *Transaction) IsCoinbase() bool {
+	return t.senderBlockchainAddress == MINING_SENDER && !t.IsRegistration()
+}
+
+// IsRegistration reports whether the transaction only records a new wallet.
+func (t *Transaction) IsRegistration() bool {
+	return t.senderBlockchainAddress == MINING_SENDER &&
+		t.value == 0 &&
+		t.message == "REGISTER USER WALLET"
+}
+
+// SigningPayload returns the fields a sender signs: everything but the
+// public key and signature.
+func (t *Transaction) SigningPayload() *signing.Payload {
+	return &signing.Payload{
+		ChainID:   t.chainID,
+		Sender:    t.senderBlockchainAddress,
+		Recipient: t.recipientBlockchainAddress,
+		Value:     t.value,
+		Fee:       t.fee,
+		Nonce:     t.nonce,
+		Message:   t.message,
+	}
+}
+
+// VerifySignature reports whether the transaction carries a valid signature
+// by its embedded sender public key.
+func (t *Transaction) VerifySignature() bool {
+	return signing.Verify(t.senderPublicKey, t.SigningPayload(), t.signature)
+}
+
+// GetChainID returns the ID of the chain the transaction is signed for.
+// Transactions created by miners carry none.
+func (t *Transaction) GetChainID() string {
+	return t.chainID
+}
+
+// Hash returns the SHA-256 of the transaction's JSON encoding, which covers
+// every field including the public key and signature. Signatures are only
+// valid in their low-S form, so nobody but the sender can produce another
+// encoding of the same transfer. It is the leaf of the transaction in its
+// block's Merkle tree.
+func (t *Transaction) Hash() [32]byte {
+	return sha256.Sum256(t.encoding())
+}
+
+// ID returns the canonical transaction ID, the hex encoded Hash.
+func (t *Transaction) ID() string {
+	h := t.Hash()
+	return hex.EncodeToString(h[:])
+}
+
+// TransactionPool returns the pooled transactions in arrival order.
 func (bc *Blockchain) TransactionPool() []*Transaction {
-	return bc.transactionPool
+	pooled := bc.pool.Transactions()
+	transactions := make([]*Transaction, len(pooled))
+	for i, t := range pooled {
+		transactions[i] = t.(*Transaction)
+	}
+	return transactions
+}
+
+// MempoolStats summarises the transaction pool.
+func (bc *Blockchain) MempoolStats() mempool.Stats {
+	return bc.pool.Stats()
 }
 
 func (tr *TransactionRequest) Validate() bool {
@@ -144,27 +310,27 @@ func (tr *TransactionRequest) Validate() bool {
 		tr.SenderPublicKey == nil ||
 		tr.Message == nil ||
 		tr.Value == nil ||
+		tr.Nonce == nil ||
 		tr.Signature == nil {
 		return false
 	}
 	return true
 }
+
 func (bc *Blockchain) VerifyTransactionSignature(senderPublicKey *ecdsa.PublicKey, s *utils.Signature, t *Transaction) bool {
-	m, err := json.Marshal(t)
-	if err != nil {
-		log.Printf("ERROR: Failed to marshal transaction for verification: %v", err)
+	if senderPublicKey == nil || s == nil {
+		log.Printf("ERROR: Missing sender public key or signature")
 		return false
 	}
 
-	log.Printf("Verifying transaction data: %s", string(m))
-
-	hash := sha256.Sum256(m)
-	log.Printf("Verification hash: %x", hash)
+	payload := t.SigningPayload()
+	log.Printf("Verifying transaction encoding: %x", payload.Encode())
+	log.Printf("Verification hash: %x", payload.Digest())
 
 	log.Printf("Verifying signature R: %x", s.R)
 	log.Printf("Verifying signature S: %x", s.S)
 
-	verified := ecdsa.Verify(senderPublicKey, hash[:], s.R, s.S)
+	verified := signing.Verify(senderPublicKey, payload, s)
 	if !verified {
 		log.Printf("ERROR: Signature verification failed for publicKey: %x, signature: %s", senderPublicKey, s.String())
 		log.Printf("Public key components - X: %x, Y: %x", senderPublicKey.X, senderPublicKey.Y)
@@ -177,47 +343,125 @@ func (t *Transaction) Print() {
 	fmt.Printf(" senderBlockchainAddress      %s\n", t.senderBlockchainAddress)
 	fmt.Printf(" recipientBlockchainAddress   %s\n", t.recipientBlockchainAddress)
 	fmt.Printf(" message                      %s\n", t.message)
-	fmt.Printf(" value                          %.1f\n", t.value)
+	fmt.Printf(" value                          %s\n", t.value)
+	fmt.Printf(" fee                            %s\n", t.fee)
+	fmt.Printf(" nonce                          %d\n", t.nonce)
 }
 
 func (br *BalanceResponse) MarshalJSON() ([]byte, error) {
 	return json.Marshal(struct {
-		Balance float32 `json:"balance"`
-		Error   string  `json:"error"`
+		Balance         amount.Amount `json:"balance"`
+		Immature        amount.Amount `json:"immature"`
+		PendingIncoming amount.Amount `json:"pendingIncoming"`
+		PendingOutgoing amount.Amount `json:"pendingOutgoing"`
+		Error           string        `json:"error"`
 	}{
-		Balance: br.Balance,
-		Error:   br.Error,
+		Balance:         br.Balance,
+		Immature:        br.Immature,
+		PendingIncoming: br.PendingIncoming,
+		PendingOutgoing: br.PendingOutgoing,
+		Error:           br.Error,
 	})
 }
 
+// seal caches the JSON encoding of a newly created transaction.
+func (t *Transaction) seal() {
+	encoded, err := t.encode()
+	if err != nil {
+		log.Printf("ERROR: Failed to marshal transaction: %v", err)
+		return
+	}
+	t.encoded = encoded
+}
+
+// encoding returns the cached JSON encoding.
+func (t *Transaction) encoding() []byte {
+	if t.encoded != nil {
+		return t.encoded
+	}
+	encoded, _ := t.encode()
+	return encoded
+}
+
 func (t *Transaction) MarshalJSON() ([]byte, error) {
+	if t.encoded != nil {
+		return t.encoded, nil
+	}
+	return t.encode()
+}
+
+func (t *Transaction) encode() ([]byte, error) {
+	var publicKey, signature string
+	if t.senderPublicKey != nil {
+		publicKey = utils.PublicKeyString(t.senderPublicKey)
+	}
+	if t.signature != nil {
+		signature = t.signature.String()
+	}
 	return json.Marshal(struct {
-		Message   string  `json:"message"`
-		Recipient string  `json:"recipientBlockchainAddress"`
-		Sender    string  `json:"senderBlockchainAddress"`
-		Value     float32 `json:"value"`
+		ChainID   string        `json:"chainId,omitempty"`
+		Message   string        `json:"message"`
+		Recipient string        `json:"recipientBlockchainAddress"`
+		Sender    string        `json:"senderBlockchainAddress"`
+		Value     amount.Amount `json:"value"`
+		Fee       amount.Amount `json:"fee"`
+		Nonce     uint64        `json:"nonce"`
+		PublicKey string        `json:"senderPublicKey,omitempty"`
+		Signature string        `json:"signature,omitempty"`
 	}{
+		ChainID:   t.chainID,
 		Message:   t.message,
 		Recipient: t.recipientBlockchainAddress,
 		Sender:    t.senderBlockchainAddress,
 		Value:     t.value,
+		Fee:       t.fee,
+		Nonce:     t.nonce,
+		PublicKey: publicKey,
+		Signature: signature,
 	})
 }
 
 func (t *Transaction) UnmarshalJSON(data []byte) error {
+	t.encoded = nil
+	var publicKey, signature string
 	v := &struct {
-		Message   *string  `json:"message"`
-		Recipient *string  `json:"recipientBlockchainAddress"`
-		Sender    *string  `json:"senderBlockchainAddress"`
-		Value     *float32 `json:"value"`
+		ChainID   *string        `json:"chainId"`
+		Message   *string        `json:"message"`
+		Recipient *string        `json:"recipientBlockchainAddress"`
+		Sender    *string        `json:"senderBlockchainAddress"`
+		Value     *amount.Amount `json:"value"`
+		Fee       *amount.Amount `json:"fee"`
+		Nonce     *uint64        `json:"nonce"`
+		PublicKey *string        `json:"senderPublicKey"`
+		Signature *string        `json:"signature"`
 	}{
+		ChainID:   &t.chainID,
 		Message:   &t.message,
 		Recipient: &t.recipientBlockchainAddress,
 		Sender:    &t.senderBlockchainAddress,
 		Value:     &t.value,
+		Fee:       &t.fee,
+		Nonce:     &t.nonce,
+		PublicKey: &publicKey,
+		Signature: &signature,
 	}
 	if err := json.Unmarshal(data, &v); err != nil {
 		return err
 	}
+	if publicKey != "" {
+		pk, err := utils.PublicKeyFromString(publicKey)
+		if err != nil {
+			return fmt.Errorf("invalid sender public key: %v", err)
+		}
+		t.senderPublicKey = pk
+	}
+	if signature != "" {
+		sig, err := utils.SignatureFromString(signature)
+		if err != nil {
+			return fmt.Errorf("invalid signature: %v", err)
+		}
+		t.signature = sig
+	}
+	t.seal()
 	return nil
 }
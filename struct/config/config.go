@@ -0,0 +1,348 @@
+// Package config holds the settings of a node that may differ between nodes
+// of one network: where it listens and stores data, how it finds peers and
+// how often it mines and syncs. Consensus rules live in the genesis file
+// instead, since every node must agree on them.
+//
+// Settings are read from defaults, then a JSON file named by -config or
+// NODE_CONFIG, then environment variables, then command-line flags, each
+// overriding the one before.
+package config
+
+import (
+	"block/struct/address"
+	"block/struct/mempool"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults of a node.
+const (
+	DEFAULT_PORT                = 5001
+	DEFAULT_LISTEN_HOST         = "0.0.0.0"
+	DEFAULT_DATA_DIR            = "data"
+	DEFAULT_GENESIS_FILE        = "genesis.json"
+	DEFAULT_KEYSTORE_DIR        = "keystore"
+	DEFAULT_MINING_INTERVAL_SEC = 20
+	DEFAULT_NEIGHBOR_SYNC_SEC   = 20
+	DEFAULT_NEIGHBOR_IP_START   = 0
+	DEFAULT_NEIGHBOR_IP_END     = 1
+	DEFAULT_NEIGHBOR_PORT_START = 5001
+	DEFAULT_NEIGHBOR_PORT_END   = 5002
+)
+
+// Range is an inclusive range of numbers, written "start-end" in flags and
+// environment variables.
+type Range struct {
+	Start uint16 `json:"start"`
+	End   uint16 `json:"end"`
+}
+
+func (r *Range) String() string {
+	return fmt.Sprintf("%d-%d", r.Start, r.End)
+}
+
+// Set parses "start-end", or a single number for a range of one.
+func (r *Range) Set(s string) error {
+	start, end, found := strings.Cut(s, "-")
+	if !found {
+		end = start
+	}
+	a, err := strconv.ParseUint(strings.TrimSpace(start), 10, 16)
+	if err != nil {
+		return fmt.Errorf("invalid range %q", s)
+	}
+	b, err := strconv.ParseUint(strings.TrimSpace(end), 10, 16)
+	if err != nil {
+		return fmt.Errorf("invalid range %q", s)
+	}
+	r.Start, r.End = uint16(a), uint16(b)
+	return nil
+}
+
+// list is a comma-separated flag value.
+type list []string
+
+func (l *list) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *list) Set(s string) error {
+	*l = splitList(s)
+	return nil
+}
+
+func splitList(s string) []string {
+	items := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Config is the configuration of one node.
+type Config struct {
+	// Port is the port the API listens on.
+	Port uint16 `json:"port"`
+	// ListenHost is the interface the API listens on.
+	ListenHost string `json:"listenHost"`
+	// DataDir holds the node's copy of the chain. Only one node may use a
+	// data directory at a time; by default each port gets its own under
+	// DEFAULT_DATA_DIR.
+	DataDir string `json:"dataDir"`
+	// GenesisFile defines the network. A missing file selects the default
+	// network.
+	GenesisFile string `json:"genesisFile"`
+
+	// KeystoreDir holds the miner key. By default it sits in the data
+	// directory, so the directory lock keeps other nodes off the key.
+	KeystoreDir string `json:"keystoreDir"`
+	// MinerAddress picks the miner key when the keystore holds several.
+	MinerAddress string `json:"minerAddress"`
+	// MinerPasswordFile holds the keystore password. The password itself is
+	// only read from the MINER_PASSWORD environment variable.
+	MinerPasswordFile string `json:"minerPasswordFile"`
+	// DisableKeyEndpoints refuses the endpoints that take or return private
+	// keys and mnemonics.
+	DisableKeyEndpoints bool `json:"disableKeyEndpoints"`
+
+	// Mining starts the mining loop with the node.
+	Mining bool `json:"mining"`
+	// MiningIntervalSec is the pause between blocks of the mining loop.
+	MiningIntervalSec int `json:"miningIntervalSec"`
+
+	// Peers are the URLs of the neighbors. When empty, neighbors are found
+	// by probing NeighborIPs offsets from our address on NeighborPorts.
+	Peers         []string `json:"peers"`
+	NeighborIPs   Range    `json:"neighborIps"`
+	NeighborPorts Range    `json:"neighborPorts"`
+	// NeighborSyncSec is the pause between neighbor refreshes.
+	NeighborSyncSec int `json:"neighborSyncSec"`
+
+	// Mempool limits.
+	MempoolMaxCount  int `json:"mempoolMaxCount"`
+	MempoolMaxBytes  int `json:"mempoolMaxBytes"`
+	MempoolExpirySec int `json:"mempoolExpirySec"`
+}
+
+// Default returns the configuration used when nothing is set.
+func Default() *Config {
+	return &Config{
+		Port:              DEFAULT_PORT,
+		ListenHost:        DEFAULT_LISTEN_HOST,
+		GenesisFile:       DEFAULT_GENESIS_FILE,
+		Mining:            true,
+		MiningIntervalSec: DEFAULT_MINING_INTERVAL_SEC,
+		Peers:             []string{},
+		NeighborIPs:       Range{Start: DEFAULT_NEIGHBOR_IP_START, End: DEFAULT_NEIGHBOR_IP_END},
+		NeighborPorts:     Range{Start: DEFAULT_NEIGHBOR_PORT_START, End: DEFAULT_NEIGHBOR_PORT_END},
+		NeighborSyncSec:   DEFAULT_NEIGHBOR_SYNC_SEC,
+		MempoolMaxCount:   mempool.DEFAULT_MAX_COUNT,
+		MempoolMaxBytes:   mempool.DEFAULT_MAX_BYTES,
+		MempoolExpirySec:  mempool.DEFAULT_EXPIRY_SEC,
+	}
+}
+
+// Load builds the configuration from defaults, the config file, the
+// environment and the command-line arguments, and validates it.
+func Load(args []string) (*Config, error) {
+	// A first pass only finds the config file; flags are applied last
+	scratch := Default()
+	fs := scratch.flagSet(os.Getenv("NODE_CONFIG"))
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	c := Default()
+	if file := fs.Lookup("config").Value.String(); file != "" {
+		if err := c.loadFile(file); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.loadEnv(); err != nil {
+		return nil, err
+	}
+	if err := c.flagSet("").Parse(args); err != nil {
+		return nil, err
+	}
+	if c.DataDir == "" {
+		c.DataDir = filepath.Join(DEFAULT_DATA_DIR, strconv.Itoa(int(c.Port)))
+	}
+	if c.KeystoreDir == "" {
+		c.KeystoreDir = filepath.Join(c.DataDir, DEFAULT_KEYSTORE_DIR)
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// flagSet binds the command-line flags to c, so flags left unset keep the
+// values already in c.
+func (c *Config) flagSet(configFile string) *flag.FlagSet {
+	fs := flag.NewFlagSet("node", flag.ContinueOnError)
+	fs.String("config", configFile, "JSON config file (NODE_CONFIG)")
+	fs.Func("port", fmt.Sprintf("API port (PORT) (default %d)", c.Port), func(s string) error {
+		port, err := parsePort(s)
+		c.Port = port
+		return err
+	})
+	fs.StringVar(&c.ListenHost, "listen", c.ListenHost, "interface the API listens on (LISTEN_HOST)")
+	fs.StringVar(&c.DataDir, "data-dir", c.DataDir, "directory holding the chain (DATA_DIR) (default data/<port>)")
+	fs.StringVar(&c.GenesisFile, "genesis", c.GenesisFile, "genesis file (GENESIS_FILE)")
+	fs.StringVar(&c.KeystoreDir, "keystore", c.KeystoreDir, "keystore holding the miner key (KEYSTORE_DIR) (default <data-dir>/keystore)")
+	fs.StringVar(&c.MinerAddress, "miner-address", c.MinerAddress, "miner key to use (MINER_ADDRESS)")
+	fs.StringVar(&c.MinerPasswordFile, "miner-password-file", c.MinerPasswordFile, "file holding the keystore password (MINER_PASSWORD_FILE)")
+	fs.BoolVar(&c.DisableKeyEndpoints, "disable-key-endpoints", c.DisableKeyEndpoints, "refuse endpoints that handle private keys (DISABLE_KEY_ENDPOINTS)")
+	fs.BoolVar(&c.Mining, "mining", c.Mining, "start mining with the node (MINING)")
+	fs.IntVar(&c.MiningIntervalSec, "mining-interval", c.MiningIntervalSec, "seconds between mined blocks (MINING_INTERVAL_SEC)")
+	fs.Var((*list)(&c.Peers), "peers", "comma-separated neighbor URLs, disables discovery (PEERS)")
+	fs.Var(&c.NeighborIPs, "neighbor-ips", "offsets from our IP address probed for neighbors (NEIGHBOR_IPS)")
+	fs.Var(&c.NeighborPorts, "neighbor-ports", "ports probed for neighbors (NEIGHBOR_PORTS)")
+	fs.IntVar(&c.NeighborSyncSec, "neighbor-sync", c.NeighborSyncSec, "seconds between neighbor refreshes (NEIGHBOR_SYNC_SEC)")
+	fs.IntVar(&c.MempoolMaxCount, "mempool-max-count", c.MempoolMaxCount, "most pooled transactions (MEMPOOL_MAX_COUNT)")
+	fs.IntVar(&c.MempoolMaxBytes, "mempool-max-bytes", c.MempoolMaxBytes, "most pooled bytes (MEMPOOL_MAX_BYTES)")
+	fs.IntVar(&c.MempoolExpirySec, "mempool-expiry", c.MempoolExpirySec, "seconds a transaction may stay pooled (MEMPOOL_EXPIRY_SEC)")
+	return fs
+}
+
+func parsePort(s string) (uint16, error) {
+	port, err := strconv.ParseUint(s, 10, 16)
+	if err != nil || port == 0 {
+		return 0, fmt.Errorf("invalid port %q", s)
+	}
+	return uint16(port), nil
+}
+
+func (c *Config) loadFile(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %v", err)
+	}
+	defer f.Close()
+
+	decoder := json.NewDecoder(f)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(c); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %v", file, err)
+	}
+	return nil
+}
+
+// loadEnv applies the environment variables that are set.
+func (c *Config) loadEnv() error {
+	var err error
+	env := func(name string, apply func(string) error) {
+		if s, ok := os.LookupEnv(name); ok && s != "" && err == nil {
+			if e := apply(s); e != nil {
+				err = fmt.Errorf("invalid %s: %v", name, e)
+			}
+		}
+	}
+	str := func(target *string) func(string) error {
+		return func(s string) error { *target = s; return nil }
+	}
+	integer := func(target *int) func(string) error {
+		return func(s string) (e error) { *target, e = strconv.Atoi(s); return }
+	}
+	boolean := func(target *bool) func(string) error {
+		return func(s string) (e error) { *target, e = strconv.ParseBool(s); return }
+	}
+
+	env("PORT", func(s string) (e error) { c.Port, e = parsePort(s); return })
+	env("LISTEN_HOST", str(&c.ListenHost))
+	env("DATA_DIR", str(&c.DataDir))
+	env("GENESIS_FILE", str(&c.GenesisFile))
+	env("KEYSTORE_DIR", str(&c.KeystoreDir))
+	env("MINER_ADDRESS", str(&c.MinerAddress))
+	env("MINER_PASSWORD_FILE", str(&c.MinerPasswordFile))
+	env("DISABLE_KEY_ENDPOINTS", boolean(&c.DisableKeyEndpoints))
+	env("MINING", boolean(&c.Mining))
+	env("MINING_INTERVAL_SEC", integer(&c.MiningIntervalSec))
+	// MINER_HOST names the containers of a three node cluster
+	env("MINER_HOST", func(host string) error {
+		c.Peers = []string{
+			"http://" + host + "-1:5001",
+			"http://" + host + "-2:5002",
+			"http://" + host + "-3:5003",
+		}
+		return nil
+	})
+	env("PEERS", func(s string) error { c.Peers = splitList(s); return nil })
+	env("NEIGHBOR_IPS", c.NeighborIPs.Set)
+	env("NEIGHBOR_PORTS", c.NeighborPorts.Set)
+	env("NEIGHBOR_SYNC_SEC", integer(&c.NeighborSyncSec))
+	env("MEMPOOL_MAX_COUNT", integer(&c.MempoolMaxCount))
+	env("MEMPOOL_MAX_BYTES", integer(&c.MempoolMaxBytes))
+	env("MEMPOOL_EXPIRY_SEC", integer(&c.MempoolExpirySec))
+	return err
+}
+
+// Validate checks that the configuration describes a node that can run.
+func (c *Config) Validate() error {
+	switch {
+	case c.Port == 0:
+		return fmt.Errorf("port must be set")
+	case c.ListenHost == "":
+		return fmt.Errorf("listen host must be set")
+	case c.DataDir == "":
+		return fmt.Errorf("data directory must be set")
+	case c.GenesisFile == "":
+		return fmt.Errorf("genesis file must be set")
+	case c.KeystoreDir == "":
+		return fmt.Errorf("keystore directory must be set")
+	case c.MiningIntervalSec <= 0:
+		return fmt.Errorf("mining interval must be positive")
+	case c.NeighborSyncSec <= 0:
+		return fmt.Errorf("neighbor sync interval must be positive")
+	case c.NeighborIPs.Start > c.NeighborIPs.End || c.NeighborIPs.End > 255:
+		return fmt.Errorf("neighbor IP range %s is invalid", &c.NeighborIPs)
+	case c.NeighborPorts.Start == 0 || c.NeighborPorts.Start > c.NeighborPorts.End:
+		return fmt.Errorf("neighbor port range %s is invalid", &c.NeighborPorts)
+	case c.MempoolMaxCount <= 0 || c.MempoolMaxBytes <= 0 || c.MempoolExpirySec <= 0:
+		return fmt.Errorf("mempool limits must be positive")
+	}
+	if c.MinerAddress != "" {
+		if err := address.Validate(c.MinerAddress); err != nil {
+			return fmt.Errorf("miner address: %w", err)
+		}
+	}
+	for _, peer := range c.Peers {
+		u, err := url.Parse(peer)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("peer %q is not an http(s) URL", peer)
+		}
+	}
+	return nil
+}
+
+// MiningInterval returns the pause between blocks of the mining loop.
+func (c *Config) MiningInterval() time.Duration {
+	return time.Duration(c.MiningIntervalSec) * time.Second
+}
+
+// NeighborSyncInterval returns the pause between neighbor refreshes.
+func (c *Config) NeighborSyncInterval() time.Duration {
+	return time.Duration(c.NeighborSyncSec) * time.Second
+}
+
+// MempoolConfig returns the limits of the transaction pool.
+func (c *Config) MempoolConfig() mempool.Config {
+	return mempool.Config{
+		MaxCount: c.MempoolMaxCount,
+		MaxBytes: c.MempoolMaxBytes,
+		Expiry:   time.Duration(c.MempoolExpirySec) * time.Second,
+	}
+}
@@ -0,0 +1,200 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// envVars are the environment variables Load reads.
+var envVars = []string{
+	"NODE_CONFIG", "PORT", "LISTEN_HOST", "DATA_DIR", "GENESIS_FILE", "KEYSTORE_DIR",
+	"MINER_ADDRESS", "MINER_PASSWORD_FILE", "DISABLE_KEY_ENDPOINTS", "MINING",
+	"MINING_INTERVAL_SEC", "MINER_HOST", "PEERS", "NEIGHBOR_IPS", "NEIGHBOR_PORTS",
+	"NEIGHBOR_SYNC_SEC", "MEMPOOL_MAX_COUNT", "MEMPOOL_MAX_BYTES", "MEMPOOL_EXPIRY_SEC",
+}
+
+// clearEnv hides the environment of the test process from Load. Empty
+// variables count as unset.
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range envVars {
+		t.Setenv(name, "")
+	}
+}
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "node.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	clearEnv(t)
+	c, err := Load(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Default()
+	want.DataDir = filepath.Join(DEFAULT_DATA_DIR, "5001")
+	want.KeystoreDir = filepath.Join(DEFAULT_DATA_DIR, "5001", DEFAULT_KEYSTORE_DIR)
+	if !reflect.DeepEqual(c, want) {
+		t.Fatalf("Load(nil) = %+v, want %+v", c, want)
+	}
+
+	// The data directory follows the port, and the keystore the data
+	// directory, unless they are set
+	c, err = Load([]string{"-port", "5003"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.DataDir != filepath.Join("data", "5003") || c.KeystoreDir != filepath.Join("data", "5003", "keystore") {
+		t.Fatalf("port 5003 uses data directory %s and keystore %s", c.DataDir, c.KeystoreDir)
+	}
+	c, err = Load([]string{"-data-dir", "/srv/node"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.KeystoreDir != filepath.Join("/srv/node", "keystore") {
+		t.Fatalf("data directory /srv/node uses keystore %s", c.KeystoreDir)
+	}
+	c, err = Load([]string{"-data-dir", "/srv/node", "-keystore", "/srv/keys"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.KeystoreDir != "/srv/keys" {
+		t.Fatalf("keystore = %s, want /srv/keys", c.KeystoreDir)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	clearEnv(t)
+	file := writeConfig(t, `{
+		"port": 6001,
+		"listenHost": "127.0.0.1",
+		"miningIntervalSec": 5,
+		"neighborSyncSec": 7,
+		"peers": ["http://file:5001"]
+	}`)
+
+	// The file overrides the defaults
+	c, err := Load([]string{"-config", file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Port != 6001 || c.ListenHost != "127.0.0.1" || c.MiningIntervalSec != 5 || c.NeighborSyncSec != 7 ||
+		!reflect.DeepEqual(c.Peers, []string{"http://file:5001"}) || c.DataDir != filepath.Join("data", "6001") {
+		t.Fatalf("Load from the file = %+v", c)
+	}
+	if c.MempoolMaxCount != Default().MempoolMaxCount {
+		t.Fatalf("a setting left out of the file lost its default: %+v", c)
+	}
+
+	// The environment overrides the file; NODE_CONFIG names it
+	t.Setenv("NODE_CONFIG", file)
+	t.Setenv("PORT", "6002")
+	t.Setenv("MINING_INTERVAL_SEC", "9")
+	t.Setenv("PEERS", "http://env-1:5001, http://env-2:5002")
+	t.Setenv("MINING", "false")
+	c, err = Load(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Port != 6002 || c.ListenHost != "127.0.0.1" || c.MiningIntervalSec != 9 || c.NeighborSyncSec != 7 || c.Mining ||
+		!reflect.DeepEqual(c.Peers, []string{"http://env-1:5001", "http://env-2:5002"}) {
+		t.Fatalf("Load from the file and environment = %+v", c)
+	}
+
+	// Flags override both
+	c, err = Load([]string{"-port", "6003", "-peers", "http://flag:5001", "-mining"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Port != 6003 || c.ListenHost != "127.0.0.1" || c.MiningIntervalSec != 9 || !c.Mining ||
+		!reflect.DeepEqual(c.Peers, []string{"http://flag:5001"}) || c.DataDir != filepath.Join("data", "6003") {
+		t.Fatalf("Load from the file, environment and flags = %+v", c)
+	}
+
+	// MINER_HOST names a three node cluster
+	t.Setenv("PEERS", "")
+	t.Setenv("MINER_HOST", "miner")
+	c, err = Load(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"http://miner-1:5001", "http://miner-2:5002", "http://miner-3:5003"}; !reflect.DeepEqual(c.Peers, want) {
+		t.Fatalf("MINER_HOST peers = %v, want %v", c.Peers, want)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		file string
+		want string
+	}{
+		{name: "unknown flag", args: []string{"-nope"}, want: "not defined"},
+		{name: "extra argument", args: []string{"extra"}, want: "unexpected argument"},
+		{name: "zero port", args: []string{"-port", "0"}, want: "invalid port"},
+		{name: "port range", args: []string{"-port", "70000"}, want: "invalid port"},
+		{name: "env port", env: map[string]string{"PORT": "http"}, want: "invalid PORT"},
+		{name: "env bool", env: map[string]string{"MINING": "maybe"}, want: "invalid MINING"},
+		{name: "env range", env: map[string]string{"NEIGHBOR_PORTS": "a-b"}, want: "invalid NEIGHBOR_PORTS"},
+		{name: "missing file", args: []string{"-config", "/nonexistent/node.json"}, want: "failed to open config file"},
+		{name: "unknown file field", file: `{"prot": 5001}`, want: "failed to parse config file"},
+		{name: "mining interval", args: []string{"-mining-interval", "0"}, want: "mining interval"},
+		{name: "neighbor sync", args: []string{"-neighbor-sync", "-1"}, want: "neighbor sync"},
+		{name: "neighbor IPs", args: []string{"-neighbor-ips", "3-1"}, want: "neighbor IP range"},
+		{name: "neighbor IPs above 255", args: []string{"-neighbor-ips", "0-256"}, want: "neighbor IP range"},
+		{name: "neighbor ports", args: []string{"-neighbor-ports", "0-5"}, want: "neighbor port range"},
+		{name: "mempool", args: []string{"-mempool-max-bytes", "0"}, want: "mempool limits"},
+		{name: "listen host", args: []string{"-listen", ""}, want: "listen host"},
+		{name: "genesis", file: `{"genesisFile": ""}`, want: "genesis file"},
+		{name: "miner address", args: []string{"-miner-address", "nope"}, want: "miner address"},
+		{name: "peer scheme", args: []string{"-peers", "ftp://peer:5001"}, want: "not an http(s) URL"},
+		{name: "peer host", args: []string{"-peers", "http://"}, want: "not an http(s) URL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			args := tt.args
+			if tt.file != "" {
+				args = append([]string{"-config", writeConfig(t, tt.file)}, args...)
+			}
+			c, err := Load(args)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Load(%q) = %+v, %v, want an error containing %q", args, c, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRange(t *testing.T) {
+	tests := map[string]Range{
+		"5001-5003": {Start: 5001, End: 5003},
+		" 1 - 2 ":   {Start: 1, End: 2},
+		"7":         {Start: 7, End: 7},
+	}
+	for s, want := range tests {
+		var r Range
+		if err := r.Set(s); err != nil || r != want {
+			t.Errorf("Set(%q) = %v, %v, want %v", s, r, err, want)
+		}
+	}
+	for _, s := range []string{"", "a-1", "1-", "70000"} {
+		var r Range
+		if err := r.Set(s); err == nil {
+			t.Errorf("Set(%q) accepted it", s)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+// Package hdkey derives P-256 key trees from a seed following SLIP-10, the
+// NIST P-256 variant of BIP-32. Normal (non-hardened) children can also be
+// derived from an extended public key, so a watch-only copy can generate
+// receiving addresses without holding any private key.
+package hdkey
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcutil/base58"
+	"golang.org/x/crypto/ripemd160"
+)
+
+// SLIP-10 constants. Indices from HARDENED up are hardened children.
+const (
+	MASTER_SECRET = "Nist256p1 seed"
+	HARDENED      = uint32(1) << 31
+	MIN_SEED_LEN  = 16
+	MAX_SEED_LEN  = 64
+)
+
+// Serialized extended public keys follow the BIP-32 layout: version, depth,
+// parent fingerprint, child index, chain code and compressed key, in base58
+// with a 4-byte checksum. PUBLIC_VERSION makes them start with "ppub". It
+// differs from the BIP-32 secp256k1 version, so a key of one curve is never
+// read as a key of the other.
+const (
+	PUBLIC_VERSION       = 0x03e25d80
+	BIP32_PUBLIC_VERSION = 0x0488b21e
+	SERIALIZED_SIZE      = 4 + 1 + 4 + 4 + 32 + 33
+	CHECKSUM_SIZE        = 4
+)
+
+var (
+	ErrHardenedFromPublic = errors.New("cannot derive a hardened child from a public key")
+	ErrInvalidSeed        = errors.New("seed must be 16 to 64 bytes")
+	ErrInvalidPath        = errors.New("invalid derivation path")
+	ErrInvalidKey         = errors.New("invalid extended key")
+)
+
+var curve = elliptic.P256()
+
+// ExtendedKey is a node of the key tree: a key and its chain code. Private
+// keys can derive every child, public keys only normal ones.
+type ExtendedKey struct {
+	privateKey  *big.Int
+	x, y        *big.Int
+	chainCode   []byte
+	depth       uint8
+	fingerprint uint32
+	index       uint32
+}
+
+// NewMasterKey returns the root of the tree for a seed.
+func NewMasterKey(seed []byte) (*ExtendedKey, error) {
+	if len(seed) < MIN_SEED_LEN || len(seed) > MAX_SEED_LEN {
+		return nil, ErrInvalidSeed
+	}
+	// Retry with the output as input until the key is in range
+	data := seed
+	for {
+		I := hmacSHA512([]byte(MASTER_SECRET), data)
+		k := new(big.Int).SetBytes(I[:32])
+		if k.Sign() != 0 && k.Cmp(curve.Params().N) < 0 {
+			return newPrivate(k, I[32:], 0, 0, 0), nil
+		}
+		data = I
+	}
+}
+
+func newPrivate(k *big.Int, chainCode []byte, depth uint8, fingerprint uint32, index uint32) *ExtendedKey {
+	x, y := curve.ScalarBaseMult(k.FillBytes(make([]byte, 32)))
+	return &ExtendedKey{
+		privateKey:  k,
+		x:           x,
+		y:           y,
+		chainCode:   append([]byte(nil), chainCode...),
+		depth:       depth,
+		fingerprint: fingerprint,
+		index:       index,
+	}
+}
+
+func hmacSHA512(key []byte, data []byte) []byte {
+	mac := hmac.New(sha512.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+func ser32(i uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, i)
+	return b
+}
+
+// IsPrivate reports whether the key can derive hardened children and sign.
+func (k *ExtendedKey) IsPrivate() bool {
+	return k.privateKey != nil
+}
+
+func (k *ExtendedKey) Depth() uint8 {
+	return k.depth
+}
+
+func (k *ExtendedKey) Index() uint32 {
+	return k.index
+}
+
+func (k *ExtendedKey) compressed() []byte {
+	return elliptic.MarshalCompressed(curve, k.x, k.y)
+}
+
+// Fingerprint identifies the key: the first 4 bytes of the RIPEMD-160 of the
+// SHA-256 of the compressed public key.
+func (k *ExtendedKey) Fingerprint() uint32 {
+	digest := sha256.Sum256(k.compressed())
+	h := ripemd160.New()
+	h.Write(digest[:])
+	return binary.BigEndian.Uint32(h.Sum(nil)[:4])
+}
+
+// Child derives the child at index. Indices from HARDENED up need a private
+// key.
+func (k *ExtendedKey) Child(index uint32) (*ExtendedKey, error) {
+	hardened := index >= HARDENED
+	if hardened && !k.IsPrivate() {
+		return nil, ErrHardenedFromPublic
+	}
+
+	var data []byte
+	if hardened {
+		data = append([]byte{0x00}, k.privateKey.FillBytes(make([]byte, 32))...)
+	} else {
+		data = k.compressed()
+	}
+	data = append(data, ser32(index)...)
+
+	n := curve.Params().N
+	for {
+		I := hmacSHA512(k.chainCode, data)
+		IL := new(big.Int).SetBytes(I[:32])
+		IR := I[32:]
+		// An out of range tweak or a zero key moves on to the next candidate
+		retry := append(append([]byte{0x01}, IR...), ser32(index)...)
+		if IL.Cmp(n) >= 0 {
+			data = retry
+			continue
+		}
+
+		if k.IsPrivate() {
+			child := new(big.Int).Add(IL, k.privateKey)
+			child.Mod(child, n)
+			if child.Sign() == 0 {
+				data = retry
+				continue
+			}
+			return newPrivate(child, IR, k.depth+1, k.Fingerprint(), index), nil
+		}
+
+		tx, ty := curve.ScalarBaseMult(I[:32])
+		// Adding the inverse point would yield infinity
+		if tx.Cmp(k.x) == 0 && ty.Cmp(k.y) != 0 {
+			data = retry
+			continue
+		}
+		x, y := curve.Add(tx, ty, k.x, k.y)
+		return &ExtendedKey{
+			x:           x,
+			y:           y,
+			chainCode:   append([]byte(nil), IR...),
+			depth:       k.depth + 1,
+			fingerprint: k.Fingerprint(),
+			index:       index,
+		}, nil
+	}
+}
+
+// ParsePath parses a path such as "m/44'/1'/0'/0/5". A trailing ' or h marks
+// a hardened index.
+func ParsePath(path string) ([]uint32, error) {
+	parts := strings.Split(path, "/")
+	if len(parts) == 0 || parts[0] != "m" {
+		return nil, fmt.Errorf("%w: %q must start with m", ErrInvalidPath, path)
+	}
+	indices := make([]uint32, 0, len(parts)-1)
+	for _, p := range parts[1:] {
+		var offset uint32
+		if strings.HasSuffix(p, "'") || strings.HasSuffix(p, "h") {
+			offset = HARDENED
+			p = p[:len(p)-1]
+		}
+		i, err := strconv.ParseUint(p, 10, 32)
+		if err != nil || uint32(i) >= HARDENED {
+			return nil, fmt.Errorf("%w: bad index %q in %q", ErrInvalidPath, p, path)
+		}
+		indices = append(indices, uint32(i)+offset)
+	}
+	return indices, nil
+}
+
+// DerivePath derives the descendant at a path relative to k.
+func (k *ExtendedKey) DerivePath(path string) (*ExtendedKey, error) {
+	indices, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	key := k
+	for _, i := range indices {
+		if key, err = key.Child(i); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// PublicKey returns the public key of the node.
+func (k *ExtendedKey) PublicKey() *ecdsa.PublicKey {
+	return &ecdsa.PublicKey{Curve: curve, X: k.x, Y: k.y}
+}
+
+// PrivateKey returns the private key of the node, or nil for a public node.
+func (k *ExtendedKey) PrivateKey() *ecdsa.PrivateKey {
+	if !k.IsPrivate() {
+		return nil
+	}
+	return &ecdsa.PrivateKey{PublicKey: *k.PublicKey(), D: new(big.Int).Set(k.privateKey)}
+}
+
+// Neuter returns the public node, which can derive normal children only.
+func (k *ExtendedKey) Neuter() *ExtendedKey {
+	return &ExtendedKey{
+		x:           k.x,
+		y:           k.y,
+		chainCode:   k.chainCode,
+		depth:       k.depth,
+		fingerprint: k.fingerprint,
+		index:       k.index,
+	}
+}
+
+// String serializes the public node.
+func (k *ExtendedKey) String() string {
+	b := make([]byte, 0, SERIALIZED_SIZE+CHECKSUM_SIZE)
+	b = append(b, ser32(PUBLIC_VERSION)...)
+	b = append(b, k.depth)
+	b = append(b, ser32(k.fingerprint)...)
+	b = append(b, ser32(k.index)...)
+	b = append(b, k.chainCode...)
+	b = append(b, k.compressed()...)
+	return base58.Encode(append(b, checksum(b)...))
+}
+
+func checksum(b []byte) []byte {
+	first := sha256.Sum256(b)
+	second := sha256.Sum256(first[:])
+	return second[:CHECKSUM_SIZE]
+}
+
+// ParsePublic parses a serialized extended public key.
+func ParsePublic(s string) (*ExtendedKey, error) {
+	b := base58.Decode(s)
+	if len(b) != SERIALIZED_SIZE+CHECKSUM_SIZE {
+		return nil, fmt.Errorf("%w: wrong length", ErrInvalidKey)
+	}
+	payload := b[:SERIALIZED_SIZE]
+	if !bytes.Equal(checksum(payload), b[SERIALIZED_SIZE:]) {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrInvalidKey)
+	}
+	switch binary.BigEndian.Uint32(payload[:4]) {
+	case PUBLIC_VERSION:
+	case BIP32_PUBLIC_VERSION:
+		return nil, fmt.Errorf("%w: a BIP-32 secp256k1 key, not a P-256 one", ErrInvalidKey)
+	default:
+		return nil, fmt.Errorf("%w: not an extended public key", ErrInvalidKey)
+	}
+	x, y := elliptic.UnmarshalCompressed(curve, payload[45:])
+	if x == nil {
+		return nil, fmt.Errorf("%w: bad public key", ErrInvalidKey)
+	}
+	return &ExtendedKey{
+		x:           x,
+		y:           y,
+		chainCode:   append([]byte(nil), payload[13:45]...),
+		depth:       payload[4],
+		fingerprint: binary.BigEndian.Uint32(payload[5:9]),
+		index:       binary.BigEndian.Uint32(payload[9:13]),
+	}, nil
+}
@@ -0,0 +1,249 @@
+package hdkey
+
+import (
+	"crypto/elliptic"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcutil/base58"
+)
+
+type node struct {
+	path        string
+	fingerprint string
+	chainCode   string
+	private     string
+	public      string
+}
+
+// SLIP-10 test vectors for nist256p1.
+var slip10Vectors = []struct {
+	name  string
+	seed  string
+	nodes []node
+}{
+	{
+		name: "test vector 1",
+		seed: "000102030405060708090a0b0c0d0e0f",
+		nodes: []node{
+			{"m", "00000000",
+				"beeb672fe4621673f722f38529c07392fecaa61015c80c34f29ce8b41b3cb6ea",
+				"612091aaa12e22dd2abef664f8a01a82cae99ad7441b7ef8110424915c268bc2",
+				"0266874dc6ade47b3ecd096745ca09bcd29638dd52c2c12117b11ed3e458cfa9e8"},
+			{"m/0'", "be6105b5",
+				"3460cea53e6a6bb5fb391eeef3237ffd8724bf0a40e94943c98b83825342ee11",
+				"6939694369114c67917a182c59ddb8cafc3004e63ca5d3b84403ba8613debc0c",
+				"0384610f5ecffe8fda089363a41f56a5c7ffc1d81b59a612d0d649b2d22355590c"},
+			{"m/0'/1", "9b02312f",
+				"4187afff1aafa8445010097fb99d23aee9f599450c7bd140b6826ac22ba21d0c",
+				"284e9d38d07d21e4e281b645089a94f4cf5a5a81369acf151a1c3a57f18b2129",
+				"03526c63f8d0b4bbbf9c80df553fe66742df4676b241dabefdef67733e070f6844"},
+			{"m/0'/1/2'", "b98005c1",
+				"98c7514f562e64e74170cc3cf304ee1ce54d6b6da4f880f313e8204c2a185318",
+				"694596e8a54f252c960eb771a3c41e7e32496d03b954aeb90f61635b8e092aa7",
+				"0359cf160040778a4b14c5f4d7b76e327ccc8c4a6086dd9451b7482b5a4972dda0"},
+			{"m/0'/1/2'/2", "0e9f3274",
+				"ba96f776a5c3907d7fd48bde5620ee374d4acfd540378476019eab70790c63a0",
+				"5996c37fd3dd2679039b23ed6f70b506c6b56b3cb5e424681fb0fa64caf82aaa",
+				"029f871f4cb9e1c97f9f4de9ccd0d4a2f2a171110c61178f84430062230833ff20"},
+			{"m/0'/1/2'/2/1000000000", "8b2b5c4b",
+				"b9b7b82d326bb9cb5b5b121066feea4eb93d5241103c9e7a18aad40f1dde8059",
+				"21c4f269ef0a5fd1badf47eeacebeeaa3de22eb8e5b0adcd0f27dd99d34d0119",
+				"02216cd26d31147f72427a453c443ed2cde8a1e53c9cc44e5ddf739725413fe3f4"},
+		},
+	},
+	{
+		// The first candidate for m/28578'/33941 has IL >= n
+		name: "derivation retry",
+		seed: "000102030405060708090a0b0c0d0e0f",
+		nodes: []node{
+			{"m/28578'", "be6105b5",
+				"e94c8ebe30c2250a14713212f6449b20f3329105ea15b652ca5bdfc68f6c65c2",
+				"06f0db126f023755d0b8d86d4591718a5210dd8d024e3e14b6159d63f53aa669",
+				"02519b5554a4872e8c9c1c847115363051ec43e93400e030ba3c36b52a3e70a5b7"},
+			{"m/28578'/33941", "3e2b7bc6",
+				"9e87fe95031f14736774cd82f25fd885065cb7c358c1edf813c72af535e83071",
+				"092154eed4af83e078ff9b84322015aefe5769e31270f62c3f66c33888335f3a",
+				"0235bfee614c0d5b2cae260000bb1d0d84b270099ad790022c1ae0b2e782efe120"},
+		},
+	},
+	{
+		// The first master key candidate of this seed is out of range
+		name: "seed retry",
+		seed: "a7305bc8df8d0951f0cb224c0e95d7707cbdf2c6ce7e8d481fec69c7ff5e9446",
+		nodes: []node{
+			{"m", "00000000",
+				"7762f9729fed06121fd13f326884c82f59aa95c57ac492ce8c9654e60efd130c",
+				"3b8c18469a4634517d6d0b65448f8e6c62091b45540a1743c5846be55d47d88f",
+				"0383619fadcde31063d8c5cb00dbfe1713f3e6fa169d8541a798752a1c1ca0cb20"},
+		},
+	},
+}
+
+func masterKey(t *testing.T, seed string) *ExtendedKey {
+	t.Helper()
+	b, err := hex.DecodeString(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	master, err := NewMasterKey(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return master
+}
+
+func checkNode(t *testing.T, k *ExtendedKey, want node) {
+	t.Helper()
+	if got := fmt.Sprintf("%08x", k.fingerprint); got != want.fingerprint {
+		t.Errorf("%s fingerprint = %s, want %s", want.path, got, want.fingerprint)
+	}
+	if got := hex.EncodeToString(k.chainCode); got != want.chainCode {
+		t.Errorf("%s chain code = %s, want %s", want.path, got, want.chainCode)
+	}
+	if got := hex.EncodeToString(k.compressed()); got != want.public {
+		t.Errorf("%s public key = %s, want %s", want.path, got, want.public)
+	}
+	if k.IsPrivate() {
+		if got := fmt.Sprintf("%064x", k.privateKey); got != want.private {
+			t.Errorf("%s private key = %s, want %s", want.path, got, want.private)
+		}
+	}
+}
+
+func TestSLIP10Vectors(t *testing.T) {
+	for _, v := range slip10Vectors {
+		t.Run(v.name, func(t *testing.T) {
+			master := masterKey(t, v.seed)
+			for _, want := range v.nodes {
+				k, err := master.DerivePath(want.path)
+				if err != nil {
+					t.Fatalf("%s: %v", want.path, err)
+				}
+				checkNode(t, k, want)
+			}
+		})
+	}
+}
+
+func TestPublicDerivationMatchesPrivate(t *testing.T) {
+	for _, v := range slip10Vectors {
+		master := masterKey(t, v.seed)
+		for _, want := range v.nodes {
+			indices, err := ParsePath(want.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(indices) == 0 || indices[len(indices)-1] >= HARDENED {
+				continue
+			}
+			parent := master
+			for _, i := range indices[:len(indices)-1] {
+				if parent, err = parent.Child(i); err != nil {
+					t.Fatal(err)
+				}
+			}
+			k, err := parent.Neuter().Child(indices[len(indices)-1])
+			if err != nil {
+				t.Fatalf("%s from the public parent: %v", want.path, err)
+			}
+			if k.IsPrivate() {
+				t.Fatalf("%s from the public parent is private", want.path)
+			}
+			checkNode(t, k, want)
+		}
+	}
+}
+
+func TestHardenedFromPublic(t *testing.T) {
+	master := masterKey(t, slip10Vectors[0].seed)
+	if _, err := master.Neuter().Child(HARDENED); !errors.Is(err, ErrHardenedFromPublic) {
+		t.Fatalf("hardened child of a public key error = %v, want ErrHardenedFromPublic", err)
+	}
+}
+
+// TestChildRetry checks the IL >= n branch directly, from the private and
+// the public parent: the first candidate for m/28578'/33941 is out of range,
+// so the child is built from the next one.
+func TestChildRetry(t *testing.T) {
+	parent, err := masterKey(t, slip10Vectors[1].seed).DerivePath("m/28578'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := uint32(33941)
+	I := hmacSHA512(parent.chainCode, append(parent.compressed(), ser32(index)...))
+	if new(big.Int).SetBytes(I[:32]).Cmp(elliptic.P256().Params().N) < 0 {
+		t.Fatal("first candidate of m/28578'/33941 is in range, the vector no longer covers the retry")
+	}
+	for _, p := range []*ExtendedKey{parent, parent.Neuter()} {
+		k, err := p.Child(index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkNode(t, k, slip10Vectors[1].nodes[1])
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	indices, err := ParsePath("m/44'/1h/0'/0/5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []uint32{44 + HARDENED, 1 + HARDENED, HARDENED, 0, 5}
+	if fmt.Sprint(indices) != fmt.Sprint(want) {
+		t.Fatalf("ParsePath = %v, want %v", indices, want)
+	}
+	for _, path := range []string{"", "44'/0", "m/", "m/x", "m/-1", "m/2147483648", "m/1''"} {
+		if _, err := ParsePath(path); !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("ParsePath(%q) error = %v, want ErrInvalidPath", path, err)
+		}
+	}
+}
+
+func TestSerialization(t *testing.T) {
+	k, err := masterKey(t, slip10Vectors[0].seed).DerivePath("m/0'/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := k.String()
+	if !strings.HasPrefix(s, "ppub") {
+		t.Fatalf("extended public key %s does not start with ppub", s)
+	}
+	parsed, err := ParsePublic(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.IsPrivate() || parsed.String() != s || parsed.Depth() != 2 || parsed.Index() != 1 {
+		t.Fatalf("ParsePublic(%s) does not round-trip", s)
+	}
+
+	// The same key under the BIP-32 secp256k1 version is refused
+	b := base58.Decode(s)[:SERIALIZED_SIZE]
+	binary.BigEndian.PutUint32(b, BIP32_PUBLIC_VERSION)
+	xpub := base58.Encode(append(b, checksum(b)...))
+	if !strings.HasPrefix(xpub, "xpub") {
+		t.Fatalf("BIP-32 serialization %s does not start with xpub", xpub)
+	}
+	if _, err := ParsePublic(xpub); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("ParsePublic(xpub) error = %v, want ErrInvalidKey", err)
+	}
+
+	tampered := []byte(s)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := ParsePublic(string(tampered)); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("ParsePublic of a corrupted key error = %v, want ErrInvalidKey", err)
+	}
+}
+
+func TestSeedLength(t *testing.T) {
+	for _, n := range []int{MIN_SEED_LEN - 1, MAX_SEED_LEN + 1} {
+		if _, err := NewMasterKey(make([]byte, n)); !errors.Is(err, ErrInvalidSeed) {
+			t.Errorf("NewMasterKey with a %d byte seed error = %v, want ErrInvalidSeed", n, err)
+		}
+	}
+}
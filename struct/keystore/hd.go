@@ -0,0 +1,131 @@
+package keystore
+
+import (
+	"block/struct/storage"
+	"block/struct/wallet"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// HD_FILE holds the encrypted seed of the keystore's HD wallet.
+const HD_FILE = "hd.json"
+
+// HDFile is the encrypted seed of an HD wallet account and the index of the
+// next receiving address to hand out. The extended public key is stored in
+// the clear so addresses can be listed without the password.
+type HDFile struct {
+	Version           int    `json:"version"`
+	Account           uint32 `json:"account"`
+	ExtendedPublicKey string `json:"extendedPublicKey"`
+	Next              uint32 `json:"next"`
+	Crypto            Crypto `json:"crypto"`
+}
+
+// Open decrypts the seed and returns the HD wallet.
+func (hf *HDFile) Open(password string) (*wallet.HDWallet, error) {
+	if hf.Version != VERSION {
+		return nil, ErrFormat
+	}
+	seed, err := open(&hf.Crypto, password, hf.ExtendedPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	hw, err := wallet.NewHDWalletFromSeed(seed, hf.Account)
+	if err != nil {
+		return nil, err
+	}
+	if hw.ExtendedPublicKey() != hf.ExtendedPublicKey {
+		return nil, fmt.Errorf("HD file seed does not match its extended public key")
+	}
+	return hw, nil
+}
+
+func (ks *Keystore) hdPath() string {
+	return filepath.Join(ks.dir, HD_FILE)
+}
+
+// CreateHD stores the seed of an HD wallet account. A keystore holds one HD
+// wallet; it refuses to replace it.
+func (ks *Keystore) CreateHD(seed []byte, account uint32, password string) (*HDFile, error) {
+	if _, err := os.Stat(ks.hdPath()); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrExists, ks.hdPath())
+	}
+	hw, err := wallet.NewHDWalletFromSeed(seed, account)
+	if err != nil {
+		return nil, err
+	}
+	c, err := seal(seed, password, hw.ExtendedPublicKey())
+	if err != nil {
+		return nil, err
+	}
+	hf := &HDFile{
+		Version:           VERSION,
+		Account:           account,
+		ExtendedPublicKey: hw.ExtendedPublicKey(),
+		Crypto:            *c,
+	}
+	if err := ks.writeHD(hf); err != nil {
+		return nil, err
+	}
+	return hf, nil
+}
+
+// GetHD reads the HD file.
+func (ks *Keystore) GetHD() (*HDFile, error) {
+	data, err := os.ReadFile(ks.hdPath())
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: no HD wallet", ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var hf HDFile
+	if err := json.Unmarshal(data, &hf); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+	return &hf, nil
+}
+
+// writeHD replaces the HD file atomically and durably, so a crash leaves the
+// old or the new version.
+func (ks *Keystore) writeHD(hf *HDFile) error {
+	data, err := json.MarshalIndent(hf, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(ks.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create keystore directory: %v", err)
+	}
+	return storage.WriteFileAtomic(ks.hdPath(), append(data, '\n'), 0600)
+}
+
+// DeriveNext derives the next receiving key of the HD wallet, stores it as a
+// key file under the same password and advances the index.
+func (ks *Keystore) DeriveNext(password string) (wallet.HDAddress, error) {
+	hf, err := ks.GetHD()
+	if err != nil {
+		return wallet.HDAddress{}, err
+	}
+	hw, err := hf.Open(password)
+	if err != nil {
+		return wallet.HDAddress{}, err
+	}
+	w, err := hw.Wallet(hf.Next)
+	if err != nil {
+		return wallet.HDAddress{}, err
+	}
+	// A key restored earlier may already be there
+	if err := ks.Import(w.PrivateKey(), password); err != nil && !errors.Is(err, ErrExists) {
+		return wallet.HDAddress{}, err
+	}
+
+	derived := wallet.HDAddress{Index: hf.Next, Address: w.BlockchainAddress()}
+	hf.Next++
+	if err := ks.writeHD(hf); err != nil {
+		return wallet.HDAddress{}, err
+	}
+	return derived, nil
+}
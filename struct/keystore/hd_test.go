@@ -0,0 +1,62 @@
+package keystore
+
+import (
+	"block/struct/wallet"
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestHD(t *testing.T) {
+	ks := NewKeystore(t.TempDir())
+	seed := bytes.Repeat([]byte{7}, 32)
+	hf, err := ks.CreateHD(seed, 0, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.CreateHD(seed, 1, "password"); !errors.Is(err, ErrExists) {
+		t.Fatalf("second CreateHD error = %v, want ErrExists", err)
+	}
+	if _, err := hf.Open("wrong"); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("Open with the wrong password error = %v, want ErrWrongPassword", err)
+	}
+
+	hw, err := wallet.NewHDWalletFromSeed(seed, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := uint32(0); i < 2; i++ {
+		derived, err := ks.DeriveNext("password")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := hw.Address(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if derived.Index != i || derived.Address != want {
+			t.Fatalf("DeriveNext = %+v, want index %d and address %s", derived, i, want)
+		}
+		if _, err := ks.Unlock(derived.Address, "password"); err != nil {
+			t.Fatalf("derived key %s does not unlock: %v", derived.Address, err)
+		}
+	}
+
+	// The extended public key is the additional data of the seed, so
+	// swapping it fails to open rather than deriving other addresses
+	other, err := wallet.NewHDWalletFromSeed(bytes.Repeat([]byte{8}, 32), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hf, err = ks.GetHD()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hf.Next != 2 {
+		t.Fatalf("HD file next index = %d, want 2", hf.Next)
+	}
+	hf.ExtendedPublicKey = other.ExtendedPublicKey()
+	if _, err := hf.Open("password"); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("Open of a swapped extended public key error = %v, want ErrWrongPassword", err)
+	}
+}
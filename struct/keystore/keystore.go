@@ -0,0 +1,304 @@
+// Package keystore stores private keys on disk encrypted with a password. The
+// password is stretched with scrypt and the key sealed with AES-256-GCM, using
+// the address as additional data so a file cannot be relabelled.
+package keystore
+
+import (
+	"block/struct/address"
+	"block/struct/storage"
+	"block/struct/utils"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Keystore file format and default scrypt cost. SCRYPT_N is the CPU/memory
+// cost; 2^17 takes about a quarter of a second and 128 MiB. Files asking for
+// more than SCRYPT_MAX_N, SCRYPT_MAX_P or SCRYPT_MAX_MEMORY bytes are
+// refused.
+const (
+	DEFAULT_DIR  = "keystore"
+	VERSION      = 1
+	KDF          = "scrypt"
+	CIPHER       = "aes-256-gcm"
+	SCRYPT_N     = 1 << 17
+	SCRYPT_R     = 8
+	SCRYPT_P     = 1
+	SCRYPT_MAX_N = 1 << 20
+	SCRYPT_MAX_P = 16
+	KEY_LEN      = 32
+	SALT_LEN     = 32
+	FILE_SUFFIX  = ".json"
+
+	// SCRYPT_MAX_MEMORY bounds 128*N*r, the bytes scrypt allocates.
+	SCRYPT_MAX_MEMORY = 128 * SCRYPT_MAX_N * SCRYPT_R
+)
+
+var (
+	ErrNotFound      = errors.New("no key for address in keystore")
+	ErrExists        = errors.New("key already in keystore")
+	ErrWrongPassword = errors.New("wrong password or corrupted key file")
+	ErrFormat        = errors.New("unsupported key file")
+)
+
+type KDFParams struct {
+	N      int    `json:"n"`
+	R      int    `json:"r"`
+	P      int    `json:"p"`
+	KeyLen int    `json:"dklen"`
+	Salt   string `json:"salt"`
+}
+
+type Crypto struct {
+	Cipher     string    `json:"cipher"`
+	CipherText string    `json:"ciphertext"`
+	Nonce      string    `json:"nonce"`
+	KDF        string    `json:"kdf"`
+	KDFParams  KDFParams `json:"kdfparams"`
+}
+
+// KeyFile is the JSON document stored for each key.
+type KeyFile struct {
+	Version   int    `json:"version"`
+	Address   string `json:"address"`
+	PublicKey string `json:"publicKey"`
+	Crypto    Crypto `json:"crypto"`
+}
+
+// Encrypt seals a private key with a password.
+func Encrypt(privateKey *ecdsa.PrivateKey, password string) (*KeyFile, error) {
+	blockchainAddress := address.FromPublicKey(&privateKey.PublicKey)
+	c, err := seal(privateKey.D.FillBytes(make([]byte, 32)), password, blockchainAddress)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyFile{
+		Version:   VERSION,
+		Address:   blockchainAddress,
+		PublicKey: utils.PublicKeyString(&privateKey.PublicKey),
+		Crypto:    *c,
+	}, nil
+}
+
+// Decrypt opens a key file and checks the key matches its address.
+func (kf *KeyFile) Decrypt(password string) (*ecdsa.PrivateKey, error) {
+	if kf.Version != VERSION {
+		return nil, ErrFormat
+	}
+	plaintext, err := open(&kf.Crypto, password, kf.Address)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := utils.PrivateKeyFromScalar(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	if !address.MatchesPublicKey(kf.Address, &privateKey.PublicKey) {
+		return nil, fmt.Errorf("key file for %s holds the key of %s", kf.Address, address.FromPublicKey(&privateKey.PublicKey))
+	}
+	return privateKey, nil
+}
+
+// seal encrypts a secret. The label is authenticated with it, so a sealed
+// secret only opens under the name it was stored with.
+func seal(plaintext []byte, password string, label string) (*Crypto, error) {
+	salt := make([]byte, SALT_LEN)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	params := KDFParams{N: SCRYPT_N, R: SCRYPT_R, P: SCRYPT_P, KeyLen: KEY_LEN, Salt: hex.EncodeToString(salt)}
+	aead, err := newAEAD(password, params)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return &Crypto{
+		Cipher:     CIPHER,
+		CipherText: hex.EncodeToString(aead.Seal(nil, nonce, plaintext, []byte(label))),
+		Nonce:      hex.EncodeToString(nonce),
+		KDF:        KDF,
+		KDFParams:  params,
+	}, nil
+}
+
+func open(c *Crypto, password string, label string) ([]byte, error) {
+	if c.KDF != KDF || c.Cipher != CIPHER {
+		return nil, ErrFormat
+	}
+	aead, err := newAEAD(password, c.KDFParams)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hex.DecodeString(c.Nonce)
+	if err != nil || len(nonce) != aead.NonceSize() {
+		return nil, ErrFormat
+	}
+	ciphertext, err := hex.DecodeString(c.CipherText)
+	if err != nil {
+		return nil, ErrFormat
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(label))
+	if err != nil {
+		return nil, ErrWrongPassword
+	}
+	return plaintext, nil
+}
+
+// checkCost refuses scrypt parameters from a key file that would take more
+// memory or time than the keystore ever asks for.
+func (p KDFParams) checkCost() error {
+	switch {
+	case p.N < 2 || p.N > SCRYPT_MAX_N:
+		return fmt.Errorf("%w: scrypt N %d out of range", ErrFormat, p.N)
+	case p.R < 1 || p.P < 1 || p.P > SCRYPT_MAX_P:
+		return fmt.Errorf("%w: scrypt r %d and p %d out of range", ErrFormat, p.R, p.P)
+	case p.R > SCRYPT_MAX_MEMORY/128/p.N:
+		return fmt.Errorf("%w: scrypt N %d and r %d need more than %d bytes", ErrFormat, p.N, p.R, SCRYPT_MAX_MEMORY)
+	case p.R*p.P >= 1<<30:
+		return fmt.Errorf("%w: scrypt r %d and p %d out of range", ErrFormat, p.R, p.P)
+	}
+	return nil
+}
+
+func newAEAD(password string, params KDFParams) (cipher.AEAD, error) {
+	salt, err := hex.DecodeString(params.Salt)
+	if err != nil || params.KeyLen != KEY_LEN {
+		return nil, ErrFormat
+	}
+	if err := params.checkCost(); err != nil {
+		return nil, err
+	}
+	key, err := scrypt.Key([]byte(password), salt, params.N, params.R, params.P, params.KeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Keystore is a directory of key files named by address.
+type Keystore struct {
+	dir string
+}
+
+func NewKeystore(dir string) *Keystore {
+	return &Keystore{dir: dir}
+}
+
+func (ks *Keystore) Dir() string {
+	return ks.dir
+}
+
+func (ks *Keystore) path(blockchainAddress string) string {
+	return filepath.Join(ks.dir, blockchainAddress+FILE_SUFFIX)
+}
+
+// List returns the addresses in the keystore.
+func (ks *Keystore) List() ([]string, error) {
+	entries, err := os.ReadDir(ks.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var addresses []string
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), FILE_SUFFIX)
+		if e.IsDir() || name == e.Name() || address.Validate(name) != nil {
+			continue
+		}
+		addresses = append(addresses, name)
+	}
+	sort.Strings(addresses)
+	return addresses, nil
+}
+
+// Get reads the key file of an address.
+func (ks *Keystore) Get(blockchainAddress string) (*KeyFile, error) {
+	if err := address.Validate(blockchainAddress); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(ks.path(blockchainAddress))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, blockchainAddress)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var kf KeyFile
+	if err := json.Unmarshal(data, &kf); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+	if kf.Address != blockchainAddress {
+		return nil, fmt.Errorf("key file %s is for address %s", ks.path(blockchainAddress), kf.Address)
+	}
+	return &kf, nil
+}
+
+// Create generates a key and stores it.
+func (ks *Keystore) Create(password string) (*ecdsa.PrivateKey, error) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	if err := ks.Import(privateKey, password); err != nil {
+		return nil, err
+	}
+	return privateKey, nil
+}
+
+// Import stores an existing key. It refuses to overwrite a key file.
+func (ks *Keystore) Import(privateKey *ecdsa.PrivateKey, password string) error {
+	kf, err := Encrypt(privateKey, password)
+	if err != nil {
+		return err
+	}
+	return ks.Write(kf)
+}
+
+// Write stores a key file. It refuses to overwrite an existing one. A key lost
+// to a crash cannot be recovered, so the file is synced before it appears
+// under its name.
+func (ks *Keystore) Write(kf *KeyFile) error {
+	data, err := json.MarshalIndent(kf, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(ks.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create keystore directory: %v", err)
+	}
+	err = storage.CreateFileAtomic(ks.path(kf.Address), append(data, '\n'), 0600)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%w: %s", ErrExists, kf.Address)
+	}
+	return err
+}
+
+// Unlock decrypts the key of an address.
+func (ks *Keystore) Unlock(blockchainAddress string, password string) (*ecdsa.PrivateKey, error) {
+	kf, err := ks.Get(blockchainAddress)
+	if err != nil {
+		return nil, err
+	}
+	return kf.Decrypt(password)
+}
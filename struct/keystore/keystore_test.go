@@ -0,0 +1,159 @@
+package keystore
+
+import (
+	"block/struct/address"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return privateKey
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	privateKey := newKey(t)
+	kf, err := Encrypt(privateKey, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kf.Address != address.FromPublicKey(&privateKey.PublicKey) || kf.Crypto.KDFParams.N != SCRYPT_N {
+		t.Fatalf("Encrypt = %+v", kf)
+	}
+
+	decrypted, err := kf.Decrypt("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decrypted.Equal(privateKey) {
+		t.Fatal("Decrypt returned another key")
+	}
+
+	if _, err := kf.Decrypt("battery staple"); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("Decrypt with the wrong password error = %v, want ErrWrongPassword", err)
+	}
+
+	// The address is authenticated, so a file cannot be relabelled
+	relabelled := *kf
+	relabelled.Address = address.FromPublicKey(&newKey(t).PublicKey)
+	if _, err := relabelled.Decrypt("correct horse"); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("Decrypt of a relabelled file error = %v, want ErrWrongPassword", err)
+	}
+}
+
+func TestDecryptRejectsFormat(t *testing.T) {
+	kf, err := Encrypt(newKey(t), "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]func(kf *KeyFile){
+		"version":    func(kf *KeyFile) { kf.Version = VERSION + 1 },
+		"kdf":        func(kf *KeyFile) { kf.Crypto.KDF = "pbkdf2" },
+		"cipher":     func(kf *KeyFile) { kf.Crypto.Cipher = "aes-128-ctr" },
+		"key length": func(kf *KeyFile) { kf.Crypto.KDFParams.KeyLen = 16 },
+		"salt":       func(kf *KeyFile) { kf.Crypto.KDFParams.Salt = "zz" },
+		"nonce":      func(kf *KeyFile) { kf.Crypto.Nonce = "00" },
+		"N too big":  func(kf *KeyFile) { kf.Crypto.KDFParams.N = SCRYPT_MAX_N << 1 },
+		"N too low":  func(kf *KeyFile) { kf.Crypto.KDFParams.N = 1 },
+		"p too big":  func(kf *KeyFile) { kf.Crypto.KDFParams.P = SCRYPT_MAX_P + 1 },
+		"r zero":     func(kf *KeyFile) { kf.Crypto.KDFParams.R = 0 },
+		"memory":     func(kf *KeyFile) { kf.Crypto.KDFParams.R = SCRYPT_R * 2; kf.Crypto.KDFParams.N = SCRYPT_MAX_N },
+	}
+	for name, change := range tests {
+		changed := *kf
+		change(&changed)
+		if _, err := changed.Decrypt("password"); !errors.Is(err, ErrFormat) {
+			t.Errorf("%s: Decrypt error = %v, want ErrFormat", name, err)
+		}
+	}
+}
+
+func TestCheckCost(t *testing.T) {
+	accepted := []KDFParams{
+		{N: SCRYPT_N, R: SCRYPT_R, P: SCRYPT_P},
+		{N: SCRYPT_MAX_N, R: SCRYPT_R, P: SCRYPT_MAX_P},
+		{N: 2, R: 1, P: 1},
+	}
+	for _, p := range accepted {
+		if err := p.checkCost(); err != nil {
+			t.Errorf("checkCost(%+v) = %v", p, err)
+		}
+	}
+	refused := []KDFParams{
+		{N: SCRYPT_MAX_N * 2, R: 1, P: 1},
+		{N: SCRYPT_MAX_N, R: SCRYPT_R + 1, P: 1},
+		{N: 1024, R: 1 << 20, P: 1},
+		{N: 1024, R: 8, P: SCRYPT_MAX_P + 1},
+		{N: 1024, R: 8, P: 0},
+	}
+	for _, p := range refused {
+		if err := p.checkCost(); !errors.Is(err, ErrFormat) {
+			t.Errorf("checkCost(%+v) = %v, want ErrFormat", p, err)
+		}
+	}
+}
+
+func TestKeystore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), DEFAULT_DIR)
+	ks := NewKeystore(dir)
+	if addresses, err := ks.List(); err != nil || len(addresses) != 0 {
+		t.Fatalf("List of a missing keystore = %v, %v, want nothing", addresses, err)
+	}
+
+	privateKey, err := ks.Create("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockchainAddress := address.FromPublicKey(&privateKey.PublicKey)
+	if addresses, err := ks.List(); err != nil || len(addresses) != 1 || addresses[0] != blockchainAddress {
+		t.Fatalf("List = %v, %v, want [%s]", addresses, err, blockchainAddress)
+	}
+	info, err := os.Stat(ks.path(blockchainAddress))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("key file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	// An existing key file is never replaced, even by the same key
+	if err := ks.Import(privateKey, "other password"); !errors.Is(err, ErrExists) {
+		t.Fatalf("Import over an existing key error = %v, want ErrExists", err)
+	}
+	unlocked, err := ks.Unlock(blockchainAddress, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !unlocked.Equal(privateKey) {
+		t.Fatal("Unlock returned another key")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("keystore holds %d files, want 1", len(entries))
+	}
+
+	unknown := address.FromPublicKey(&newKey(t).PublicKey)
+	if _, err := ks.Unlock(unknown, "password"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Unlock of an unknown address error = %v, want ErrNotFound", err)
+	}
+
+	// A file stored under another address's name is refused
+	if err := os.Rename(ks.path(blockchainAddress), ks.path(unknown)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.Get(unknown); err == nil {
+		t.Fatal("Get accepted a key file stored under another address")
+	}
+}
@@ -0,0 +1,391 @@
+package mempool
+
+import (
+	"block/struct/amount"
+	"errors"
+	"fmt"
+	"math/bits"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Default limits of a pool.
+const (
+	DEFAULT_MAX_COUNT  = 5000
+	DEFAULT_MAX_BYTES  = 8 << 20
+	DEFAULT_EXPIRY_SEC = 24 * 60 * 60
+)
+
+var (
+	ErrDuplicate         = errors.New("transaction already in pool")
+	ErrInsufficientFunds = errors.New("insufficient funds including pending spends")
+	ErrNonce             = errors.New("unexpected nonce")
+	ErrPoolFull          = errors.New("pool is full")
+	ErrTooLarge          = errors.New("transaction exceeds pool size limit")
+)
+
+// Tx is what the pool needs to know about a transaction.
+type Tx interface {
+	ID() string
+	GetSender() string
+	GetNonce() uint64
+	GetFee() amount.Amount
+	Cost() (amount.Amount, error)
+	Size() int
+}
+
+// Ledger exposes the confirmed account state new transactions build on.
+type Ledger interface {
+	// Balance returns what the address may spend according to the chain.
+	Balance(address string) amount.Amount
+	// Nonce returns the next nonce the chain expects from the address.
+	Nonce(address string) uint64
+}
+
+// Config bounds a pool.
+type Config struct {
+	MaxCount int           `json:"maxCount"`
+	MaxBytes int           `json:"maxBytes"`
+	Expiry   time.Duration `json:"expiry"`
+}
+
+// DefaultConfig returns the limits used when none are configured.
+func DefaultConfig() Config {
+	return Config{
+		MaxCount: DEFAULT_MAX_COUNT,
+		MaxBytes: DEFAULT_MAX_BYTES,
+		Expiry:   DEFAULT_EXPIRY_SEC * time.Second,
+	}
+}
+
+type entry struct {
+	tx    Tx
+	cost  amount.Amount
+	size  int
+	added time.Time
+}
+
+// Pool holds transactions waiting to be mined. Each sender's transactions
+// form a gapless nonce sequence continuing the sender's confirmed nonce, and
+// their summed cost never exceeds the sender's confirmed balance. It is safe
+// for concurrent use.
+type Pool struct {
+	mux      sync.Mutex
+	config   Config
+	entries  map[string]*entry
+	bySender map[string][]*entry
+	order    []*entry
+	bytes    int
+	now      func() time.Time
+}
+
+// New returns an empty pool with the given limits.
+func New(config Config) *Pool {
+	return &Pool{
+		config:   config,
+		entries:  make(map[string]*entry),
+		bySender: make(map[string][]*entry),
+		now:      time.Now,
+	}
+}
+
+// Add admits tx if it is new, continues its sender's nonce sequence and is
+// covered by the sender's balance minus pending spends. When the pool is
+// full, lower fee-rate transactions are evicted to make room, otherwise tx
+// is rejected.
+func (p *Pool) Add(tx Tx, ledger Ledger) error {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	p.expireLocked()
+
+	id := tx.ID()
+	if _, ok := p.entries[id]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicate, id)
+	}
+
+	sender := tx.GetSender()
+	if expected := ledger.Nonce(sender) + uint64(len(p.bySender[sender])); tx.GetNonce() != expected {
+		return fmt.Errorf("%w: got %d, expected %d", ErrNonce, tx.GetNonce(), expected)
+	}
+
+	cost, err := tx.Cost()
+	if err != nil {
+		return err
+	}
+	pending, err := p.pendingSpendLocked(sender)
+	if err != nil {
+		return err
+	}
+	total, err := pending.Add(cost)
+	if err != nil {
+		return err
+	}
+	if balance := ledger.Balance(sender); total > balance {
+		return fmt.Errorf("%w: %s has %s, pending %s, needs %s more", ErrInsufficientFunds, sender, balance, pending, cost)
+	}
+
+	e := &entry{tx: tx, cost: cost, size: tx.Size(), added: p.now()}
+	if e.size > p.config.MaxBytes {
+		return fmt.Errorf("%w: %d bytes", ErrTooLarge, e.size)
+	}
+	if err := p.makeRoomLocked(e); err != nil {
+		return err
+	}
+
+	p.entries[id] = e
+	p.bySender[sender] = append(p.bySender[sender], e)
+	p.order = append(p.order, e)
+	p.bytes += e.size
+	return nil
+}
+
+// higherFeeRate reports whether a pays more per byte than b.
+func higherFeeRate(a *entry, b *entry) bool {
+	ahi, alo := bits.Mul64(uint64(a.tx.GetFee()), uint64(b.size))
+	bhi, blo := bits.Mul64(uint64(b.tx.GetFee()), uint64(a.size))
+	return ahi > bhi || (ahi == bhi && alo > blo)
+}
+
+// makeRoomLocked evicts the cheapest transactions that end their sender's
+// nonce sequence until e fits, as long as each victim pays a lower fee rate
+// than e. The transactions of e's own sender are never evicted, since e
+// follows them in the nonce sequence. Victims are picked before any is
+// evicted, so a rejected e leaves the pool untouched.
+func (p *Pool) makeRoomLocked(e *entry) error {
+	sender := e.tx.GetSender()
+	// kept is how many of each sender's transactions survive the victims
+	// picked so far
+	kept := make(map[string]int, len(p.bySender))
+	for s, txs := range p.bySender {
+		kept[s] = len(txs)
+	}
+	count, bytes := len(p.entries), p.bytes
+	var victims []*entry
+	for count+1 > p.config.MaxCount || bytes+e.size > p.config.MaxBytes {
+		var victim *entry
+		for s, n := range kept {
+			if s == sender || n == 0 {
+				continue
+			}
+			last := p.bySender[s][n-1]
+			if victim == nil || higherFeeRate(victim, last) {
+				victim = last
+			}
+		}
+		if victim == nil || !higherFeeRate(e, victim) {
+			return ErrPoolFull
+		}
+		kept[victim.tx.GetSender()]--
+		count--
+		bytes -= victim.size
+		victims = append(victims, victim)
+	}
+	for _, victim := range victims {
+		p.removeLocked(victim)
+	}
+	return nil
+}
+
+// removeLocked drops e together with every later transaction of its sender,
+// which could not be mined without it.
+func (p *Pool) removeLocked(e *entry) {
+	sender := e.tx.GetSender()
+	txs := p.bySender[sender]
+	for i, other := range txs {
+		if other != e {
+			continue
+		}
+		for _, dropped := range txs[i:] {
+			delete(p.entries, dropped.tx.ID())
+			p.bytes -= dropped.size
+		}
+		if i == 0 {
+			delete(p.bySender, sender)
+		} else {
+			p.bySender[sender] = txs[:i]
+		}
+		break
+	}
+	p.compactOrderLocked()
+}
+
+func (p *Pool) compactOrderLocked() {
+	order := p.order[:0]
+	for _, e := range p.order {
+		if _, ok := p.entries[e.tx.ID()]; ok {
+			order = append(order, e)
+		}
+	}
+	for i := len(order); i < len(p.order); i++ {
+		p.order[i] = nil
+	}
+	p.order = order
+}
+
+func (p *Pool) pendingSpendLocked(sender string) (amount.Amount, error) {
+	var total amount.Amount
+	var err error
+	for _, e := range p.bySender[sender] {
+		if total, err = total.Add(e.cost); err != nil {
+			return 0, err
+		}
+	}
+	return total, nil
+}
+
+// expireLocked drops transactions older than the configured expiry, along
+// with their sender's later transactions.
+func (p *Pool) expireLocked() []Tx {
+	if p.config.Expiry <= 0 {
+		return nil
+	}
+	cutoff := p.now().Add(-p.config.Expiry)
+	var expired []Tx
+	for _, e := range append([]*entry(nil), p.order...) {
+		if _, ok := p.entries[e.tx.ID()]; ok && e.added.Before(cutoff) {
+			for _, dropped := range p.bySender[e.tx.GetSender()] {
+				if dropped.tx.GetNonce() >= e.tx.GetNonce() {
+					expired = append(expired, dropped.tx)
+				}
+			}
+			p.removeLocked(e)
+		}
+	}
+	return expired
+}
+
+// Expire drops transactions that have been pending longer than the expiry
+// and returns them.
+func (p *Pool) Expire() []Tx {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	return p.expireLocked()
+}
+
+// Remove drops the transactions with the given IDs, for example because a
+// block confirmed them. The sender's later transactions stay.
+func (p *Pool) Remove(ids ...string) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	for _, id := range ids {
+		e, ok := p.entries[id]
+		if !ok {
+			continue
+		}
+		delete(p.entries, id)
+		p.bytes -= e.size
+		sender := e.tx.GetSender()
+		txs := p.bySender[sender]
+		for i, other := range txs {
+			if other == e {
+				txs = append(txs[:i:i], txs[i+1:]...)
+				break
+			}
+		}
+		if len(txs) == 0 {
+			delete(p.bySender, sender)
+		} else {
+			p.bySender[sender] = txs
+		}
+	}
+	p.compactOrderLocked()
+}
+
+// Prune re-checks every sender's transactions against a new ledger state,
+// dropping those whose nonce is no longer next in line or whose sender can
+// no longer pay for them. It returns the dropped transactions.
+func (p *Pool) Prune(ledger Ledger) []Tx {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	var dropped []Tx
+	for sender, txs := range p.bySender {
+		sort.SliceStable(txs, func(i, j int) bool { return txs[i].tx.GetNonce() < txs[j].tx.GetNonce() })
+
+		nonce := ledger.Nonce(sender)
+		balance := ledger.Balance(sender)
+		var spent amount.Amount
+		keep := txs[:0]
+		for _, e := range txs {
+			total, err := spent.Add(e.cost)
+			if e.tx.GetNonce() != nonce+uint64(len(keep)) || err != nil || total > balance {
+				delete(p.entries, e.tx.ID())
+				p.bytes -= e.size
+				dropped = append(dropped, e.tx)
+				continue
+			}
+			spent = total
+			keep = append(keep, e)
+		}
+		if len(keep) == 0 {
+			delete(p.bySender, sender)
+		} else {
+			p.bySender[sender] = keep
+		}
+	}
+	p.compactOrderLocked()
+	return dropped
+}
+
+// Has reports whether the pool holds the transaction.
+func (p *Pool) Has(id string) bool {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	_, ok := p.entries[id]
+	return ok
+}
+
+// Get returns the pooled transaction with the given ID, or nil.
+func (p *Pool) Get(id string) Tx {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	if e, ok := p.entries[id]; ok {
+		return e.tx
+	}
+	return nil
+}
+
+// Transactions returns the pooled transactions in arrival order.
+func (p *Pool) Transactions() []Tx {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	txs := make([]Tx, len(p.order))
+	for i, e := range p.order {
+		txs[i] = e.tx
+	}
+	return txs
+}
+
+// Count returns the number of pooled transactions.
+func (p *Pool) Count() int {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	return len(p.entries)
+}
+
+// PendingCount returns how many transactions of the sender are pooled.
+func (p *Pool) PendingCount(sender string) int {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	return len(p.bySender[sender])
+}
+
+// PendingSpend returns the summed cost of the sender's pooled transactions.
+func (p *Pool) PendingSpend(sender string) (amount.Amount, error) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	return p.pendingSpendLocked(sender)
+}
+
+// Clear empties the pool.
+func (p *Pool) Clear() {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	p.entries = make(map[string]*entry)
+	p.bySender = make(map[string][]*entry)
+	p.order = nil
+	p.bytes = 0
+}
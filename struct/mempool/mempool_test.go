@@ -0,0 +1,217 @@
+package mempool
+
+import (
+	"block/struct/amount"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+)
+
+type testTx struct {
+	sender string
+	nonce  uint64
+	fee    amount.Amount
+	value  amount.Amount
+	size   int
+}
+
+func (t *testTx) ID() string                   { return fmt.Sprintf("%s/%d", t.sender, t.nonce) }
+func (t *testTx) GetSender() string            { return t.sender }
+func (t *testTx) GetNonce() uint64             { return t.nonce }
+func (t *testTx) GetFee() amount.Amount        { return t.fee }
+func (t *testTx) Cost() (amount.Amount, error) { return t.fee.Add(t.value + 1) }
+
+func (t *testTx) Size() int {
+	if t.size == 0 {
+		return 100
+	}
+	return t.size
+}
+
+type testLedger struct{}
+
+func (testLedger) Balance(address string) amount.Amount { return amount.MustFromCoins(1) }
+func (testLedger) Nonce(address string) uint64          { return 0 }
+
+// accounts is a ledger with a balance and next nonce per address.
+type accounts map[string]struct {
+	balance amount.Amount
+	nonce   uint64
+}
+
+func (a accounts) Balance(address string) amount.Amount { return a[address].balance }
+func (a accounts) Nonce(address string) uint64          { return a[address].nonce }
+
+func add(t *testing.T, p *Pool, ledger Ledger, txs ...*testTx) {
+	t.Helper()
+	for _, tx := range txs {
+		if err := p.Add(tx, ledger); err != nil {
+			t.Fatalf("Add(%s): %v", tx.ID(), err)
+		}
+	}
+}
+
+func ids(txs []Tx) []string {
+	var ids []string
+	for _, tx := range txs {
+		ids = append(ids, tx.ID())
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestEvictionSparesIncomingSender(t *testing.T) {
+	p := New(Config{MaxCount: 2, MaxBytes: 1 << 20})
+	add(t, p, testLedger{}, &testTx{sender: "a", fee: 1}, &testTx{sender: "b", fee: 20})
+
+	// a's only transaction is the cheapest, but evicting it would leave a
+	// gap before a/1, so nothing can make room
+	if err := p.Add(&testTx{sender: "a", nonce: 1, fee: 10}, testLedger{}); !errors.Is(err, ErrPoolFull) {
+		t.Fatalf("Add(a/1) error = %v, want ErrPoolFull", err)
+	}
+
+	add(t, p, testLedger{}, &testTx{sender: "c", fee: 10})
+	if p.Count() != 2 || p.PendingCount("a") != 0 || p.PendingCount("c") != 1 {
+		t.Fatalf("pool holds %d transactions, a has %d, c has %d; want a evicted for c",
+			p.Count(), p.PendingCount("a"), p.PendingCount("c"))
+	}
+}
+
+func TestEvictionByFeeRate(t *testing.T) {
+	p := New(Config{MaxCount: 10, MaxBytes: 300})
+	add(t, p, testLedger{},
+		&testTx{sender: "a", fee: 1},
+		&testTx{sender: "b", fee: 50},
+		&testTx{sender: "c", fee: 2})
+
+	// d needs two slots' worth of bytes: a and c both pay less per byte
+	add(t, p, testLedger{}, &testTx{sender: "d", fee: 30, size: 200})
+	got := ids(p.Transactions())
+	if fmt.Sprint(got) != "[b/0 d/0]" {
+		t.Fatalf("pool holds %v, want [b/0 d/0]", got)
+	}
+}
+
+func TestFailedAddEvictsNothing(t *testing.T) {
+	p := New(Config{MaxCount: 10, MaxBytes: 300})
+	add(t, p, testLedger{},
+		&testTx{sender: "a", fee: 1},
+		&testTx{sender: "b", fee: 50},
+		&testTx{sender: "c", fee: 40})
+
+	// Evicting a alone does not free enough bytes and c pays more per byte
+	// than d, so d is refused and a must stay
+	if err := p.Add(&testTx{sender: "d", fee: 30, size: 200}, testLedger{}); !errors.Is(err, ErrPoolFull) {
+		t.Fatalf("Add(d/0) error = %v, want ErrPoolFull", err)
+	}
+	if got := ids(p.Transactions()); fmt.Sprint(got) != "[a/0 b/0 c/0]" {
+		t.Fatalf("pool holds %v after a refused Add, want [a/0 b/0 c/0]", got)
+	}
+	if p.bytes != 300 {
+		t.Fatalf("pool counts %d bytes, want 300", p.bytes)
+	}
+}
+
+func TestTooLarge(t *testing.T) {
+	p := New(Config{MaxCount: 10, MaxBytes: 300})
+	if err := p.Add(&testTx{sender: "a", size: 301}, testLedger{}); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Add of 301 bytes error = %v, want ErrTooLarge", err)
+	}
+}
+
+func TestPendingSpendOverdraft(t *testing.T) {
+	p := New(DefaultConfig())
+	ledger := accounts{"a": {balance: 1000}}
+
+	add(t, p, ledger, &testTx{sender: "a", value: 600, fee: 9})
+	if spend, err := p.PendingSpend("a"); err != nil || spend != 610 {
+		t.Fatalf("PendingSpend = %s, %v, want 610", spend, err)
+	}
+
+	// On its own a/1 is covered, but not on top of a/0
+	if err := p.Add(&testTx{sender: "a", nonce: 1, value: 400}, ledger); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("Add(a/1) error = %v, want ErrInsufficientFunds", err)
+	}
+	add(t, p, ledger, &testTx{sender: "a", nonce: 1, value: 389})
+	if spend, err := p.PendingSpend("a"); err != nil || spend != 1000 {
+		t.Fatalf("PendingSpend = %s, %v, want 1000", spend, err)
+	}
+}
+
+func TestNonceSequence(t *testing.T) {
+	p := New(DefaultConfig())
+	ledger := accounts{"a": {balance: 1000, nonce: 5}}
+
+	for _, nonce := range []uint64{4, 6} {
+		if err := p.Add(&testTx{sender: "a", nonce: nonce}, ledger); !errors.Is(err, ErrNonce) {
+			t.Fatalf("Add(a/%d) error = %v, want ErrNonce", nonce, err)
+		}
+	}
+	add(t, p, ledger, &testTx{sender: "a", nonce: 5})
+	if err := p.Add(&testTx{sender: "a", nonce: 7}, ledger); !errors.Is(err, ErrNonce) {
+		t.Fatalf("Add(a/7) after a/5 error = %v, want ErrNonce", err)
+	}
+	add(t, p, ledger, &testTx{sender: "a", nonce: 6})
+}
+
+func TestDuplicate(t *testing.T) {
+	p := New(DefaultConfig())
+	tx := &testTx{sender: "a"}
+	add(t, p, testLedger{}, tx)
+	if err := p.Add(tx, testLedger{}); !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("second Add error = %v, want ErrDuplicate", err)
+	}
+	if p.Count() != 1 || !p.Has(tx.ID()) || p.Get(tx.ID()) != tx {
+		t.Fatalf("pool holds %d transactions, want only %s", p.Count(), tx.ID())
+	}
+}
+
+func TestExpire(t *testing.T) {
+	p := New(Config{MaxCount: 10, MaxBytes: 1 << 20, Expiry: time.Hour})
+	now := time.Unix(1700000000, 0)
+	p.now = func() time.Time { return now }
+
+	add(t, p, testLedger{}, &testTx{sender: "a"})
+	now = now.Add(30 * time.Minute)
+	add(t, p, testLedger{}, &testTx{sender: "a", nonce: 1}, &testTx{sender: "b"})
+
+	// a/0 expires and takes a/1 with it, b/0 is still fresh
+	now = now.Add(31 * time.Minute)
+	if got := ids(p.Expire()); fmt.Sprint(got) != "[a/0 a/1]" {
+		t.Fatalf("Expire = %v, want [a/0 a/1]", got)
+	}
+	if got := ids(p.Transactions()); fmt.Sprint(got) != "[b/0]" {
+		t.Fatalf("pool holds %v, want [b/0]", got)
+	}
+
+	now = now.Add(time.Hour)
+	if got := ids(p.Expire()); fmt.Sprint(got) != "[b/0]" || p.Count() != 0 {
+		t.Fatalf("Expire = %v, leaving %d, want [b/0] and an empty pool", got, p.Count())
+	}
+}
+
+func TestPrune(t *testing.T) {
+	p := New(DefaultConfig())
+	before := accounts{"a": {balance: 1000}, "b": {balance: 1000}, "c": {balance: 1000}}
+	add(t, p, before,
+		&testTx{sender: "a", value: 99},
+		&testTx{sender: "a", nonce: 1, value: 99},
+		&testTx{sender: "a", nonce: 2, value: 99},
+		&testTx{sender: "b", value: 99},
+		&testTx{sender: "c", value: 99})
+
+	// A block confirmed a/0 and spent most of a's and all of b's balance
+	after := accounts{"a": {balance: 150, nonce: 1}, "c": {balance: 1000}}
+	if got := ids(p.Prune(after)); fmt.Sprint(got) != "[a/0 a/2 b/0]" {
+		t.Fatalf("Prune = %v, want [a/0 a/2 b/0]", got)
+	}
+	if got := ids(p.Transactions()); fmt.Sprint(got) != "[a/1 c/0]" {
+		t.Fatalf("pool holds %v, want [a/1 c/0]", got)
+	}
+	if p.bytes != 200 || p.PendingCount("a") != 1 || p.PendingCount("b") != 0 {
+		t.Fatalf("pool counts %d bytes, a has %d, b has %d", p.bytes, p.PendingCount("a"), p.PendingCount("b"))
+	}
+	add(t, p, after, &testTx{sender: "a", nonce: 2, value: 1})
+}
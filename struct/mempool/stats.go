@@ -0,0 +1,67 @@
+package mempool
+
+import (
+	"block/struct/amount"
+	"sort"
+	"time"
+)
+
+// SenderStats summarises one sender's pooled transactions.
+type SenderStats struct {
+	Address      string        `json:"address"`
+	Count        int           `json:"count"`
+	Bytes        int           `json:"bytes"`
+	PendingSpend amount.Amount `json:"pendingSpend"`
+	Fees         amount.Amount `json:"fees"`
+	FirstNonce   uint64        `json:"firstNonce"`
+	LastNonce    uint64        `json:"lastNonce"`
+	OldestSec    int64         `json:"oldestSec"`
+}
+
+// Stats summarises the pool.
+type Stats struct {
+	Count    int           `json:"count"`
+	Bytes    int           `json:"bytes"`
+	MaxCount int           `json:"maxCount"`
+	MaxBytes int           `json:"maxBytes"`
+	Senders  []SenderStats `json:"senders"`
+}
+
+// Stats returns pool totals and per-sender statistics, senders sorted by
+// address. Sums that would overflow saturate.
+func (p *Pool) Stats() Stats {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	now := p.now()
+	stats := Stats{
+		Count:    len(p.entries),
+		Bytes:    p.bytes,
+		MaxCount: p.config.MaxCount,
+		MaxBytes: p.config.MaxBytes,
+		Senders:  make([]SenderStats, 0, len(p.bySender)),
+	}
+	for sender, txs := range p.bySender {
+		s := SenderStats{Address: sender, Count: len(txs), FirstNonce: txs[0].tx.GetNonce()}
+		for _, e := range txs {
+			s.Bytes += e.size
+			s.PendingSpend = saturatingAdd(s.PendingSpend, e.cost)
+			s.Fees = saturatingAdd(s.Fees, e.tx.GetFee())
+			s.LastNonce = e.tx.GetNonce()
+			if age := int64(now.Sub(e.added) / time.Second); age > s.OldestSec {
+				s.OldestSec = age
+			}
+		}
+		stats.Senders = append(stats.Senders, s)
+	}
+	sort.Slice(stats.Senders, func(i, j int) bool { return stats.Senders[i].Address < stats.Senders[j].Address })
+	return stats
+}
+
+func saturatingAdd(a amount.Amount, b amount.Amount) amount.Amount {
+	sum, err := a.Add(b)
+	if err != nil {
+		return ^amount.Amount(0)
+	}
+	return sum
+}
@@ -0,0 +1,165 @@
+// Package signing defines the byte string a transaction sender signs. Every
+// signer and verifier, in this repository or elsewhere, must produce exactly
+// these bytes; testvectors.json holds reference encodings.
+//
+// Encoding, version 1:
+//
+//	version    1 byte, 0x01
+//	chainId    string
+//	sender     string
+//	recipient  string
+//	value      uint64, base units, big-endian
+//	fee        uint64, base units, big-endian
+//	nonce      uint64, big-endian
+//	message    string
+//
+// Each string is a 4-byte big-endian length followed by that many bytes of
+// UTF-8. The signature is ECDSA on P-256 over the SHA-256 of the encoding.
+// Only low-S signatures, with s at most half the curve order, are valid, so
+// a third party cannot turn a signature into a second valid one.
+package signing
+
+import (
+	"block/struct/amount"
+	"block/struct/utils"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// VERSION is the encoding version written as the first byte.
+const VERSION byte = 1
+
+var (
+	ErrVersion   = errors.New("unsupported signing encoding version")
+	ErrMalformed = errors.New("malformed signing encoding")
+)
+
+// halfOrder is half the order of P-256, the largest s of a low-S signature.
+var halfOrder = new(big.Int).Rsh(elliptic.P256().Params().N, 1)
+
+// Payload holds the transaction fields covered by a signature. Its JSON form
+// is an unsigned transaction.
+type Payload struct {
+	ChainID   string        `json:"chainId"`
+	Sender    string        `json:"senderBlockchainAddress"`
+	Recipient string        `json:"recipientBlockchainAddress"`
+	Value     amount.Amount `json:"value"`
+	Fee       amount.Amount `json:"fee"`
+	Nonce     uint64        `json:"nonce"`
+	Message   string        `json:"message"`
+}
+
+// Encode returns the canonical encoding of the payload.
+func (p *Payload) Encode() []byte {
+	size := 1 + 4*4 + len(p.ChainID) + len(p.Sender) + len(p.Recipient) + len(p.Message) + 3*8
+	b := make([]byte, 0, size)
+	b = append(b, VERSION)
+	b = appendString(b, p.ChainID)
+	b = appendString(b, p.Sender)
+	b = appendString(b, p.Recipient)
+	b = binary.BigEndian.AppendUint64(b, uint64(p.Value))
+	b = binary.BigEndian.AppendUint64(b, uint64(p.Fee))
+	b = binary.BigEndian.AppendUint64(b, p.Nonce)
+	b = appendString(b, p.Message)
+	return b
+}
+
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(s)))
+	return append(b, s...)
+}
+
+// Decode parses an encoding produced by Encode.
+func Decode(b []byte) (*Payload, error) {
+	if len(b) == 0 {
+		return nil, ErrMalformed
+	}
+	if b[0] != VERSION {
+		return nil, fmt.Errorf("%w: %d", ErrVersion, b[0])
+	}
+	d := decoder{b: b[1:]}
+	p := &Payload{
+		ChainID:   d.string(),
+		Sender:    d.string(),
+		Recipient: d.string(),
+		Value:     amount.Amount(d.uint64()),
+		Fee:       amount.Amount(d.uint64()),
+		Nonce:     d.uint64(),
+		Message:   d.string(),
+	}
+	if d.err != nil || len(d.b) != 0 {
+		return nil, ErrMalformed
+	}
+	return p, nil
+}
+
+type decoder struct {
+	b   []byte
+	err error
+}
+
+func (d *decoder) take(n uint64) []byte {
+	if d.err != nil || uint64(len(d.b)) < n {
+		d.err = ErrMalformed
+		return nil
+	}
+	v := d.b[:n]
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *decoder) uint64() uint64 {
+	v := d.take(8)
+	if v == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(v)
+}
+
+func (d *decoder) string() string {
+	n := d.take(4)
+	if n == nil {
+		return ""
+	}
+	return string(d.take(uint64(binary.BigEndian.Uint32(n))))
+}
+
+// Digest returns the SHA-256 of the encoding, the value that is signed.
+func (p *Payload) Digest() [32]byte {
+	return sha256.Sum256(p.Encode())
+}
+
+// Sign signs the payload with the private key, returning a low-S signature.
+func Sign(privateKey *ecdsa.PrivateKey, p *Payload) (*utils.Signature, error) {
+	digest := p.Digest()
+	r, s, err := ecdsa.Sign(rand.Reader, privateKey, digest[:])
+	if err != nil {
+		return nil, err
+	}
+	if s.Cmp(halfOrder) > 0 {
+		s.Sub(privateKey.Curve.Params().N, s)
+	}
+	return &utils.Signature{R: r, S: s}, nil
+}
+
+// IsLowS reports whether s is at most half the curve order. (r, n-s) is
+// valid wherever (r, s) is, so only the low form is accepted.
+func IsLowS(s *utils.Signature) bool {
+	return s != nil && s.S != nil && s.S.Cmp(halfOrder) <= 0
+}
+
+// Verify reports whether s is a low-S signature of the payload by the public
+// key.
+func Verify(publicKey *ecdsa.PublicKey, p *Payload, s *utils.Signature) bool {
+	if publicKey == nil || !IsLowS(s) {
+		return false
+	}
+	digest := p.Digest()
+	return ecdsa.Verify(publicKey, digest[:], s.R, s.S)
+}
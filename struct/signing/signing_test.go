@@ -0,0 +1,165 @@
+package signing
+
+import (
+	"block/struct/address"
+	"block/struct/amount"
+	"block/struct/utils"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"strconv"
+	"testing"
+)
+
+type vector struct {
+	Name       string `json:"name"`
+	ChainID    string `json:"chainId"`
+	Sender     string `json:"sender"`
+	Recipient  string `json:"recipient"`
+	Value      string `json:"value"`
+	Fee        string `json:"fee"`
+	Nonce      string `json:"nonce"`
+	Message    string `json:"message"`
+	Encoding   string `json:"encoding"`
+	Digest     string `json:"digest"`
+	PrivateKey string `json:"privateKey"`
+	PublicKey  string `json:"publicKey"`
+	Signature  string `json:"signature"`
+}
+
+func loadVectors(t *testing.T) []vector {
+	t.Helper()
+	data, err := os.ReadFile("testvectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file struct {
+		Version int      `json:"version"`
+		Vectors []vector `json:"vectors"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatal(err)
+	}
+	if file.Version != int(VERSION) || len(file.Vectors) == 0 {
+		t.Fatalf("testvectors.json has version %d and %d vectors", file.Version, len(file.Vectors))
+	}
+	return file.Vectors
+}
+
+func parseUint(t *testing.T, s string) uint64 {
+	t.Helper()
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func (v *vector) payload(t *testing.T) *Payload {
+	return &Payload{
+		ChainID:   v.ChainID,
+		Sender:    v.Sender,
+		Recipient: v.Recipient,
+		Value:     amount.Amount(parseUint(t, v.Value)),
+		Fee:       amount.Amount(parseUint(t, v.Fee)),
+		Nonce:     parseUint(t, v.Nonce),
+		Message:   v.Message,
+	}
+}
+
+func TestVectors(t *testing.T) {
+	for _, v := range loadVectors(t) {
+		t.Run(v.Name, func(t *testing.T) {
+			p := v.payload(t)
+
+			encoding := p.Encode()
+			if got := hex.EncodeToString(encoding); got != v.Encoding {
+				t.Fatalf("encoding = %s, want %s", got, v.Encoding)
+			}
+			digest := p.Digest()
+			if got := hex.EncodeToString(digest[:]); got != v.Digest {
+				t.Fatalf("digest = %s, want %s", got, v.Digest)
+			}
+			decoded, err := Decode(encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *decoded != *p {
+				t.Fatalf("Decode = %+v, want %+v", decoded, p)
+			}
+
+			publicKey, err := utils.PublicKeyFromString(v.PublicKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := address.FromPublicKey(publicKey); got != v.Sender {
+				t.Fatalf("address of public key = %s, want sender %s", got, v.Sender)
+			}
+			signature, err := utils.SignatureFromString(v.Signature)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !Verify(publicKey, p, signature) {
+				t.Fatal("published signature does not verify")
+			}
+
+			// A changed field must break the signature
+			tampered := *p
+			tampered.Nonce++
+			if Verify(publicKey, &tampered, signature) {
+				t.Fatal("signature verifies for a different nonce")
+			}
+
+			// The mirrored high-S signature is valid ECDSA but not accepted
+			high := &utils.Signature{R: signature.R, S: new(big.Int).Sub(elliptic.P256().Params().N, signature.S)}
+			if !ecdsa.Verify(publicKey, digest[:], high.R, high.S) || Verify(publicKey, p, high) {
+				t.Fatal("high-S signature is accepted")
+			}
+
+			// Fresh signatures by the vector key verify too
+			privateKey, err := utils.PrivateKeyFromHex(v.PrivateKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s, err := Sign(privateKey, p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !IsLowS(s) || !Verify(publicKey, p, s) {
+				t.Fatal("fresh signature does not verify")
+			}
+		})
+	}
+}
+
+func TestDecodeRejectsUnknownVersion(t *testing.T) {
+	v := loadVectors(t)[0]
+	encoding, err := hex.DecodeString(v.Encoding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, version := range []byte{0, VERSION + 1, 0xff} {
+		b := bytes.Clone(encoding)
+		b[0] = version
+		if _, err := Decode(b); !errors.Is(err, ErrVersion) {
+			t.Errorf("Decode with version %d error = %v, want ErrVersion", version, err)
+		}
+	}
+}
+
+func TestDecodeRejectsMalformed(t *testing.T) {
+	encoding, err := hex.DecodeString(loadVectors(t)[0].Encoding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range [][]byte{nil, encoding[:len(encoding)-1], append(bytes.Clone(encoding), 0)} {
+		if _, err := Decode(b); !errors.Is(err, ErrMalformed) {
+			t.Errorf("Decode of %d bytes error = %v, want ErrMalformed", len(b), err)
+		}
+	}
+}
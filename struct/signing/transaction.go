@@ -0,0 +1,101 @@
+package signing
+
+import (
+	"block/struct/address"
+	"block/struct/amount"
+	"block/struct/utils"
+	"crypto/ecdsa"
+	"fmt"
+)
+
+// SignedTransaction is a transaction signed by its sender, in the form nodes
+// accept on /transactions. It carries the public key and signature only, so
+// private keys never leave the machine that signs.
+type SignedTransaction struct {
+	ChainID                    string        `json:"chainId"`
+	SenderBlockchainAddress    string        `json:"senderBlockchainAddress"`
+	RecipientBlockchainAddress string        `json:"recipientBlockchainAddress"`
+	Message                    string        `json:"message"`
+	Value                      amount.Amount `json:"value"`
+	Fee                        amount.Amount `json:"fee"`
+	Nonce                      uint64        `json:"nonce"`
+	SenderPublicKey            string        `json:"senderPublicKey"`
+	Signature                  string        `json:"signature"`
+}
+
+// Check reports what would make a node reject the payload before it looks
+// at balances and nonces.
+func (p *Payload) Check() error {
+	if p.ChainID == "" {
+		return fmt.Errorf("chain ID is required")
+	}
+	if err := address.Validate(p.Sender); err != nil {
+		return fmt.Errorf("sender: %w", err)
+	}
+	if err := address.Validate(p.Recipient); err != nil {
+		return fmt.Errorf("recipient: %w", err)
+	}
+	if p.Value == 0 {
+		return fmt.Errorf("value must be positive")
+	}
+	return nil
+}
+
+// SignTransaction checks the payload and signs it with the sender's key.
+func SignTransaction(privateKey *ecdsa.PrivateKey, p *Payload) (*SignedTransaction, error) {
+	if err := p.Check(); err != nil {
+		return nil, err
+	}
+	if !address.MatchesPublicKey(p.Sender, &privateKey.PublicKey) {
+		return nil, fmt.Errorf("sender %s is not the address of the signing key, which is %s",
+			p.Sender, address.FromPublicKey(&privateKey.PublicKey))
+	}
+	signature, err := Sign(privateKey, p)
+	if err != nil {
+		return nil, err
+	}
+	return &SignedTransaction{
+		ChainID:                    p.ChainID,
+		SenderBlockchainAddress:    p.Sender,
+		RecipientBlockchainAddress: p.Recipient,
+		Message:                    p.Message,
+		Value:                      p.Value,
+		Fee:                        p.Fee,
+		Nonce:                      p.Nonce,
+		SenderPublicKey:            utils.PublicKeyString(&privateKey.PublicKey),
+		Signature:                  signature.String(),
+	}, nil
+}
+
+// Payload returns the signed fields.
+func (st *SignedTransaction) Payload() *Payload {
+	return &Payload{
+		ChainID:   st.ChainID,
+		Sender:    st.SenderBlockchainAddress,
+		Recipient: st.RecipientBlockchainAddress,
+		Value:     st.Value,
+		Fee:       st.Fee,
+		Nonce:     st.Nonce,
+		Message:   st.Message,
+	}
+}
+
+// Verify checks the signature and that the sender address belongs to the
+// public key.
+func (st *SignedTransaction) Verify() error {
+	publicKey, err := utils.PublicKeyFromString(st.SenderPublicKey)
+	if err != nil {
+		return err
+	}
+	if !address.MatchesPublicKey(st.SenderBlockchainAddress, publicKey) {
+		return fmt.Errorf("sender %s is not the address of the public key", st.SenderBlockchainAddress)
+	}
+	signature, err := utils.SignatureFromString(st.Signature)
+	if err != nil {
+		return err
+	}
+	if !Verify(publicKey, st.Payload(), signature) {
+		return fmt.Errorf("signature does not verify")
+	}
+	return nil
+}
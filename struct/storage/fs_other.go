@@ -0,0 +1,16 @@
+//go:build !unix && !windows
+
+package storage
+
+import (
+	"errors"
+	"os"
+)
+
+func lockFile(path string) (*os.File, error) {
+	return nil, errors.New("locking data directories is not supported on this platform")
+}
+
+func syncDir(dir string) error {
+	return nil
+}
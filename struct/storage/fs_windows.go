@@ -0,0 +1,36 @@
+//go:build windows
+
+package storage
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// ERROR_SHARING_VIOLATION is returned when another process has the file open.
+const ERROR_SHARING_VIOLATION syscall.Errno = 32
+
+func lockFile(path string) (*os.File, error) {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	// Opening without sharing keeps every other process out until we exit
+	handle, err := syscall.CreateFile(name,
+		syscall.GENERIC_READ|syscall.GENERIC_WRITE, 0, nil,
+		syscall.OPEN_ALWAYS, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+	if err != nil {
+		if errors.Is(err, ERROR_SHARING_VIOLATION) {
+			return nil, ErrLocked
+		}
+		return nil, err
+	}
+	return os.NewFile(uintptr(handle), path), nil
+}
+
+// syncDir is a no-op: Windows cannot sync directories, and renames are
+// journaled by NTFS.
+func syncDir(dir string) error {
+	return nil
+}
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// Journal record framing: a little-endian payload length and the CRC-32C of
+// the payload precede every payload.
+const (
+	RECORD_HEADER_SIZE = 8
+	MAX_RECORD_SIZE    = 64 << 20
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Journal is an append-only log of records. Every append is synced before it
+// returns, and a record cut short by a crash is detected and dropped when the
+// journal is replayed.
+type Journal struct {
+	file *os.File
+}
+
+// OpenJournal opens or creates the journal at path.
+func OpenJournal(path string) (*Journal, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &Journal{file: file}, nil
+}
+
+// Replay calls apply with every intact record, oldest first. It stops at the
+// first torn or corrupt record, one cut short or failing its length or
+// checksum, and cuts the journal there so later appends follow the last good
+// record. It returns the number of bytes dropped. An intact record that apply
+// rejects is not a torn write: Replay then returns the error and leaves the
+// journal untouched.
+func (j *Journal) Replay(apply func(record []byte) error) (int64, error) {
+	if _, err := j.file.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	info, err := j.file.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	reader := bufio.NewReader(j.file)
+	var good int64
+	header := make([]byte, RECORD_HEADER_SIZE)
+	for {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			return 0, err
+		}
+		size := binary.LittleEndian.Uint32(header[0:4])
+		if size > MAX_RECORD_SIZE || int64(size) > info.Size()-good-RECORD_HEADER_SIZE {
+			break
+		}
+		record := make([]byte, size)
+		if _, err := io.ReadFull(reader, record); err != nil {
+			break
+		}
+		if crc32.Checksum(record, crcTable) != binary.LittleEndian.Uint32(header[4:8]) {
+			break
+		}
+		if err := apply(record); err != nil {
+			return 0, fmt.Errorf("record at offset %d: %w", good, err)
+		}
+		good += RECORD_HEADER_SIZE + int64(size)
+	}
+
+	dropped := info.Size() - good
+	if dropped > 0 {
+		if err := j.truncate(good); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := j.file.Seek(0, io.SeekEnd); err != nil {
+		return 0, err
+	}
+	return dropped, nil
+}
+
+// Append writes a record and syncs it to disk.
+func (j *Journal) Append(record []byte) error {
+	if len(record) > MAX_RECORD_SIZE {
+		return fmt.Errorf("journal record of %d bytes exceeds %d", len(record), MAX_RECORD_SIZE)
+	}
+	buf := make([]byte, RECORD_HEADER_SIZE+len(record))
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(record)))
+	binary.LittleEndian.PutUint32(buf[4:8], crc32.Checksum(record, crcTable))
+	copy(buf[RECORD_HEADER_SIZE:], record)
+	if _, err := j.file.Write(buf); err != nil {
+		return err
+	}
+	return j.file.Sync()
+}
+
+// Reset empties the journal, once its records are covered by a snapshot.
+func (j *Journal) Reset() error {
+	return j.truncate(0)
+}
+
+func (j *Journal) truncate(size int64) error {
+	if err := j.file.Truncate(size); err != nil {
+		return err
+	}
+	if _, err := j.file.Seek(size, io.SeekStart); err != nil {
+		return err
+	}
+	return j.file.Sync()
+}
+
+func (j *Journal) Close() error {
+	return j.file.Close()
+}
@@ -0,0 +1,173 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func openJournal(t *testing.T, path string) *Journal {
+	t.Helper()
+	j, err := OpenJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { j.Close() })
+	return j
+}
+
+func appendRecords(t *testing.T, j *Journal, records ...string) {
+	t.Helper()
+	for _, r := range records {
+		if err := j.Append([]byte(r)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func replay(t *testing.T, j *Journal) ([]string, int64) {
+	t.Helper()
+	var records []string
+	dropped, err := j.Replay(func(record []byte) error {
+		records = append(records, string(record))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return records, dropped
+}
+
+func fileSize(t *testing.T, path string) int64 {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Size()
+}
+
+func TestJournalReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	j := openJournal(t, path)
+	appendRecords(t, j, "one", "two", "")
+	j.Close()
+
+	j = openJournal(t, path)
+	records, dropped := replay(t, j)
+	if fmt.Sprintf("%q", records) != `["one" "two" ""]` || dropped != 0 {
+		t.Fatalf("Replay = %q, dropped %d, want all three records", records, dropped)
+	}
+
+	// Appends after a replay follow the last record
+	appendRecords(t, j, "three")
+	if records, _ = replay(t, j); len(records) != 4 || records[3] != "three" {
+		t.Fatalf("Replay after append = %q", records)
+	}
+}
+
+func TestJournalTornTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	j := openJournal(t, path)
+	appendRecords(t, j, "one", "two")
+	good := fileSize(t, path)
+	appendRecords(t, j, "three")
+	j.Close()
+
+	// Cut the last record short, as a crash during the write would
+	for _, size := range []int64{good + 3, good + RECORD_HEADER_SIZE + 2} {
+		if err := os.Truncate(path, size); err != nil {
+			t.Fatal(err)
+		}
+		j := openJournal(t, path)
+		records, dropped := replay(t, j)
+		if fmt.Sprintf("%q", records) != `["one" "two"]` || dropped != size-good {
+			t.Fatalf("Replay of a journal cut at %d = %q, dropped %d", size, records, dropped)
+		}
+		if got := fileSize(t, path); got != good {
+			t.Fatalf("journal is %d bytes after recovery, want %d", got, good)
+		}
+		appendRecords(t, j, "three")
+		j.Close()
+	}
+}
+
+func TestJournalCorruptRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	j := openJournal(t, path)
+	appendRecords(t, j, "one")
+	good := fileSize(t, path)
+	appendRecords(t, j, "two", "three")
+	j.Close()
+
+	// A flipped payload byte fails the checksum: the record and everything
+	// after it are dropped
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[good+RECORD_HEADER_SIZE] ^= 0xff
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	records, dropped := replay(t, openJournal(t, path))
+	if fmt.Sprintf("%q", records) != `["one"]` || dropped != int64(len(data))-good {
+		t.Fatalf("Replay = %q, dropped %d, want only the first record", records, dropped)
+	}
+
+	// So is a length beyond the end of the file
+	j = openJournal(t, path)
+	replay(t, j)
+	appendRecords(t, j, "two")
+	data, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[good] = 0xff
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if records, _ := replay(t, openJournal(t, path)); len(records) != 1 {
+		t.Fatalf("Replay with a bad length = %q, want only the first record", records)
+	}
+}
+
+func TestJournalApplyError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	j := openJournal(t, path)
+	appendRecords(t, j, "one", "two", "three")
+	size := fileSize(t, path)
+
+	// A record apply rejects is intact, so the journal must not be cut
+	errReject := errors.New("rejected")
+	_, err := j.Replay(func(record []byte) error {
+		if string(record) == "two" {
+			return errReject
+		}
+		return nil
+	})
+	if !errors.Is(err, errReject) {
+		t.Fatalf("Replay error = %v, want the apply error", err)
+	}
+	if got := fileSize(t, path); got != size {
+		t.Fatalf("journal is %d bytes after a rejected record, want %d", got, size)
+	}
+	if records, _ := replay(t, j); len(records) != 3 {
+		t.Fatalf("Replay = %q, want all three records", records)
+	}
+}
+
+func TestJournalReset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	j := openJournal(t, path)
+	appendRecords(t, j, "one", "two")
+	if err := j.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	appendRecords(t, j, "three")
+	if records, _ := replay(t, j); fmt.Sprintf("%q", records) != `["three"]` {
+		t.Fatalf("Replay after Reset = %q", records)
+	}
+}
@@ -46,6 +46,12 @@ func SignatureFromString(s string) (*Signature, error) {
 	return &Signature{R: x, S: y}, nil
 }
 
+// PublicKeyString encodes an ECDSA public key as the 128-character hex string
+// accepted by PublicKeyFromString.
+func PublicKeyString(publicKey *ecdsa.PublicKey) string {
+	return fmt.Sprintf("%064x%064x", publicKey.X, publicKey.Y)
+}
+
 // PublicKeyFromString converts a hex string to an ECDSA public key.
 func PublicKeyFromString(s string) (*ecdsa.PublicKey, error) {
 	x, y, err := String2BigIntTuple(s)
@@ -65,3 +71,33 @@ func PrivateKeyFromString(s string, publicKey *ecdsa.PublicKey) (*ecdsa.PrivateK
 	bi.SetBytes(b)
 	return &ecdsa.PrivateKey{PublicKey: *publicKey, D: &bi}, nil
 }
+
+// PrivateKeyString encodes an ECDSA private key as a 64-character hex string.
+func PrivateKeyString(privateKey *ecdsa.PrivateKey) string {
+	return fmt.Sprintf("%064x", privateKey.D)
+}
+
+// PrivateKeyFromScalar returns the P-256 key with the given big-endian scalar,
+// deriving its public key.
+func PrivateKeyFromScalar(d []byte) (*ecdsa.PrivateKey, error) {
+	curve := elliptic.P256()
+	k := new(big.Int).SetBytes(d)
+	if k.Sign() == 0 || k.Cmp(curve.Params().N) >= 0 {
+		return nil, fmt.Errorf("private key is out of range")
+	}
+	privateKey := &ecdsa.PrivateKey{PublicKey: ecdsa.PublicKey{Curve: curve}, D: k}
+	privateKey.PublicKey.X, privateKey.PublicKey.Y = curve.ScalarBaseMult(k.FillBytes(make([]byte, 32)))
+	return privateKey, nil
+}
+
+// PrivateKeyFromHex parses a hex private key and derives its public key.
+func PrivateKeyFromHex(s string) (*ecdsa.PrivateKey, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode private key: %v", err)
+	}
+	if len(b) > 32 {
+		return nil, fmt.Errorf("invalid private key length: got %d bytes, expected 32", len(b))
+	}
+	return PrivateKeyFromScalar(b)
+}
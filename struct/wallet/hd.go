@@ -0,0 +1,164 @@
+package wallet
+
+import (
+	"block/struct/address"
+	"block/struct/hdkey"
+	"errors"
+	"fmt"
+
+	"github.com/tyler-smith/go-bip39"
+)
+
+// HD wallet layout. Receiving addresses are the normal children of the
+// receive chain, m/44'/1'/account'/0/index, so the extended public key of the
+// chain can generate them without the seed. MNEMONIC_ENTROPY_BITS gives 24
+// words.
+const (
+	HD_PURPOSE            = 44
+	HD_COIN_TYPE          = 1
+	HD_RECEIVE_CHAIN      = 0
+	MNEMONIC_ENTROPY_BITS = 256
+	HD_GAP_LIMIT          = 20
+)
+
+var ErrInvalidMnemonic = errors.New("invalid mnemonic")
+
+// HDAddress is a receiving address and its index in the receive chain.
+type HDAddress struct {
+	Index   uint32 `json:"index"`
+	Address string `json:"address"`
+}
+
+// HDWallet derives the receiving accounts of one HD account.
+type HDWallet struct {
+	chain *hdkey.ExtendedKey
+}
+
+// NewMnemonic returns a new random BIP-39 mnemonic.
+func NewMnemonic() (string, error) {
+	entropy, err := bip39.NewEntropy(MNEMONIC_ENTROPY_BITS)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// ReceivePath returns the derivation path of an account's receive chain.
+func ReceivePath(account uint32) string {
+	return fmt.Sprintf("m/%d'/%d'/%d'/%d", HD_PURPOSE, HD_COIN_TYPE, account, HD_RECEIVE_CHAIN)
+}
+
+// NewHDWalletFromSeed returns the wallet of an account of a seed.
+func NewHDWalletFromSeed(seed []byte, account uint32) (*HDWallet, error) {
+	master, err := hdkey.NewMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := master.DerivePath(ReceivePath(account))
+	if err != nil {
+		return nil, err
+	}
+	return &HDWallet{chain: chain}, nil
+}
+
+// SeedFromMnemonic checks a mnemonic and returns its seed under an optional
+// passphrase.
+func SeedFromMnemonic(mnemonic string, passphrase string) ([]byte, error) {
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMnemonic, err)
+	}
+	return seed, nil
+}
+
+// NewHDWalletFromMnemonic restores the wallet of an account from its
+// mnemonic and optional passphrase.
+func NewHDWalletFromMnemonic(mnemonic string, passphrase string, account uint32) (*HDWallet, error) {
+	seed, err := SeedFromMnemonic(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return NewHDWalletFromSeed(seed, account)
+}
+
+// NewWatchOnlyHDWallet returns a wallet that derives addresses from the
+// extended public key of a receive chain but cannot sign.
+func NewWatchOnlyHDWallet(extendedPublicKey string) (*HDWallet, error) {
+	chain, err := hdkey.ParsePublic(extendedPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &HDWallet{chain: chain}, nil
+}
+
+// ExtendedPublicKey serializes the receive chain for watch-only use.
+func (hw *HDWallet) ExtendedPublicKey() string {
+	return hw.chain.Neuter().String()
+}
+
+// IsWatchOnly reports whether the wallet lacks private keys.
+func (hw *HDWallet) IsWatchOnly() bool {
+	return !hw.chain.IsPrivate()
+}
+
+// Address returns the receiving address at index.
+func (hw *HDWallet) Address(index uint32) (string, error) {
+	child, err := hw.chain.Child(index)
+	if err != nil {
+		return "", err
+	}
+	return address.FromPublicKey(child.PublicKey()), nil
+}
+
+// Wallet returns the signing wallet of the receiving address at index.
+func (hw *HDWallet) Wallet(index uint32) (*Wallet, error) {
+	if hw.IsWatchOnly() {
+		return nil, fmt.Errorf("watch-only wallet cannot derive private keys")
+	}
+	child, err := hw.chain.Child(index)
+	if err != nil {
+		return nil, err
+	}
+	return NewWalletFromPrivateKey(child.PrivateKey()), nil
+}
+
+// NextUnused returns the first address from index start that is not used.
+func (hw *HDWallet) NextUnused(start uint32, used func(string) bool) (HDAddress, error) {
+	for index := start; index < hdkey.HARDENED; index++ {
+		a, err := hw.Address(index)
+		if err != nil {
+			return HDAddress{}, err
+		}
+		if !used(a) {
+			return HDAddress{Index: index, Address: a}, nil
+		}
+	}
+	return HDAddress{}, fmt.Errorf("no unused address left")
+}
+
+// Scan walks the receive chain until gap consecutive addresses are unused,
+// returning the used addresses and the first unused one.
+func (hw *HDWallet) Scan(gap uint32, used func(string) bool) ([]HDAddress, HDAddress, error) {
+	var found []HDAddress
+	var next *HDAddress
+	for index, unused := uint32(0), uint32(0); unused < gap && index < hdkey.HARDENED; index++ {
+		a, err := hw.Address(index)
+		if err != nil {
+			return nil, HDAddress{}, err
+		}
+		if used(a) {
+			found = append(found, HDAddress{Index: index, Address: a})
+			next = nil
+			unused = 0
+			continue
+		}
+		if next == nil {
+			next = &HDAddress{Index: index, Address: a}
+		}
+		unused++
+	}
+	if next == nil {
+		return nil, HDAddress{}, fmt.Errorf("no unused address left")
+	}
+	return found, *next, nil
+}
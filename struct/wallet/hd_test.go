@@ -0,0 +1,98 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/tyler-smith/go-bip39"
+)
+
+// BIP-39 test vectors, all with the passphrase "TREZOR".
+var bip39Vectors = []struct {
+	entropy  string
+	mnemonic string
+	seed     string
+}{
+	{
+		"00000000000000000000000000000000",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		"c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+	},
+	{
+		"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+		"legal winner thank year wave sausage worth useful legal winner thank yellow",
+		"2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+	},
+	{
+		"ffffffffffffffffffffffffffffffff",
+		"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong",
+		"ac27495480225222079d7be181583751e86f571027b0497b5b5d11218e0a8a13332572917f0f8e5a589620c6f15b11c61dee327651a14c34e18231052e48c069",
+	},
+	{
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon " +
+			"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon art",
+		"bda85446c68413707090a52022edd26a1c9462295029f2e60cd7c4f2bbd3097170af7a4d73245cafa9c3cca8d561a7c3de6f5d4a10be8ed2a5e608d68f92fcc8",
+	},
+}
+
+func TestBIP39Vectors(t *testing.T) {
+	for _, v := range bip39Vectors {
+		entropy, err := hex.DecodeString(v.entropy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mnemonic, err := bip39.NewMnemonic(entropy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mnemonic != v.mnemonic {
+			t.Errorf("mnemonic of %s = %q, want %q", v.entropy, mnemonic, v.mnemonic)
+		}
+		seed, err := SeedFromMnemonic(v.mnemonic, "TREZOR")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(seed); got != v.seed {
+			t.Errorf("seed of %q = %s, want %s", v.mnemonic, got, v.seed)
+		}
+	}
+}
+
+func TestSeedFromMnemonicRejectsBadChecksum(t *testing.T) {
+	bad := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon"
+	if _, err := SeedFromMnemonic(bad, ""); !errors.Is(err, ErrInvalidMnemonic) {
+		t.Fatalf("SeedFromMnemonic error = %v, want ErrInvalidMnemonic", err)
+	}
+}
+
+func TestWatchOnlyMatchesSeed(t *testing.T) {
+	hw, err := NewHDWalletFromMnemonic(bip39Vectors[3].mnemonic, "TREZOR", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	watch, err := NewWatchOnlyHDWallet(hw.ExtendedPublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hw.IsWatchOnly() || !watch.IsWatchOnly() {
+		t.Fatal("only the wallet without a seed should be watch-only")
+	}
+	for i := uint32(0); i < 3; i++ {
+		a, err := hw.Address(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := watch.Address(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a != b {
+			t.Fatalf("address %d = %s from the seed and %s from the extended public key", i, a, b)
+		}
+	}
+	if _, err := watch.Wallet(0); err == nil {
+		t.Fatal("watch-only wallet returned a signing wallet")
+	}
+}
@@ -1,17 +1,16 @@
 package wallet
 
 import (
+	"block/struct/address"
+	"block/struct/amount"
+	"block/struct/signing"
 	"block/struct/utils"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
-	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"log"
-
-	"github.com/btcsuite/btcutil/base58"
-	"golang.org/x/crypto/ripemd160"
 )
 
 type Wallet struct {
@@ -21,21 +20,27 @@ type Wallet struct {
 }
 
 type Transaction struct {
+	chainID                    string
 	message                    string
 	recipientBlockchainAddress string
 	senderBlockchainAddress    string
 	senderPrivateKey           *ecdsa.PrivateKey
 	senderPublicKey            *ecdsa.PublicKey
-	value                      float32
+	value                      amount.Amount
+	fee                        amount.Amount
+	nonce                      uint64
 }
 
 type TransactionRequest struct {
-	Message                    *string  `json:"message"`
-	RecipientBlockchainAddress *string  `json:"recipientBlockchainAddress"`
-	SenderBlockchainAddress    *string  `json:"senderBlockchainAddress"`
-	SenderPublicKey            *string  `json:"senderPublicKey"`
-	SenderPrivateKey           *string  `json:"senderPrivateKey"`
-	Value                      *float32 `json:"value"`
+	ChainID                    *string        `json:"chainId"`
+	Message                    *string        `json:"message"`
+	RecipientBlockchainAddress *string        `json:"recipientBlockchainAddress"`
+	SenderBlockchainAddress    *string        `json:"senderBlockchainAddress"`
+	SenderPublicKey            *string        `json:"senderPublicKey"`
+	SenderPrivateKey           *string        `json:"senderPrivateKey"`
+	Value                      *amount.Amount `json:"value"`
+	Fee                        *amount.Amount `json:"fee"`
+	Nonce                      *uint64        `json:"nonce"`
 }
 
 func NewWallet() *Wallet {
@@ -43,39 +48,19 @@ func NewWallet() *Wallet {
 	privateKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	w.privateKey = privateKey
 	w.publicKey = &w.privateKey.PublicKey
-
-	h2 := sha256.New()
-	h2.Write(w.publicKey.X.Bytes())
-	h2.Write(w.publicKey.Y.Bytes())
-	digest2 := h2.Sum(nil)
-
-	h3 := ripemd160.New()
-	h3.Write(digest2)
-	digest3 := h3.Sum(nil)
-
-	vd4 := make([]byte, 21)
-	vd4[0] = 0x00
-	copy(vd4[1:], digest3[:])
-
-	h5 := sha256.New()
-	h5.Write(vd4)
-	digest5 := h5.Sum(nil)
-
-	h6 := sha256.New()
-	h6.Write(digest5)
-	digest6 := h6.Sum(nil)
-
-	chsum := digest6[:4]
-
-	dc8 := make([]byte, 25)
-	copy(dc8[:21], vd4[:])
-	copy(dc8[21:], chsum[:])
-
-	address := base58.Encode(dc8)
-	w.blockchainAddress = address
+	w.blockchainAddress = address.FromPublicKey(w.publicKey)
 	return w
 }
 
+// NewWalletFromPrivateKey returns the wallet of an existing key.
+func NewWalletFromPrivateKey(privateKey *ecdsa.PrivateKey) *Wallet {
+	return &Wallet{
+		privateKey:        privateKey,
+		publicKey:         &privateKey.PublicKey,
+		blockchainAddress: address.FromPublicKey(&privateKey.PublicKey),
+	}
+}
+
 func (w *Wallet) PublicKey() *ecdsa.PublicKey {
 	return w.publicKey
 }
@@ -85,11 +70,11 @@ func (w *Wallet) PrivateKey() *ecdsa.PrivateKey {
 }
 
 func (w *Wallet) PrivateKeyStr() string {
-	return fmt.Sprintf("%x", w.privateKey.D.Bytes())
+	return utils.PrivateKeyString(w.privateKey)
 }
 
 func (w *Wallet) PublicKeyStr() string {
-	return fmt.Sprintf("%x%x", w.publicKey.X.Bytes(), w.publicKey.Y.Bytes())
+	return utils.PublicKeyString(w.publicKey)
 }
 
 func (w *Wallet) BlockchainAddress() string {
@@ -109,40 +94,59 @@ func (w *Wallet) MarshalJSON() ([]byte, error) {
 }
 
 func NewTransaction(
+	chainID string,
 	message string,
 	sender string,
 	recipient string,
 	privateKey *ecdsa.PrivateKey,
 	publicKey *ecdsa.PublicKey,
-	value float32) *Transaction {
-	return &Transaction{message, recipient, sender, privateKey, publicKey, value}
+	value amount.Amount,
+	fee amount.Amount,
+	nonce uint64) *Transaction {
+	return &Transaction{chainID, message, recipient, sender, privateKey, publicKey, value, fee, nonce}
 }
 
-func (t *Transaction) GenerateSignature() *utils.Signature {
-	m, _ := json.Marshal(t)
+// SigningPayload returns the fields covered by the signature.
+func (t *Transaction) SigningPayload() *signing.Payload {
+	return &signing.Payload{
+		ChainID:   t.chainID,
+		Sender:    t.senderBlockchainAddress,
+		Recipient: t.recipientBlockchainAddress,
+		Value:     t.value,
+		Fee:       t.fee,
+		Nonce:     t.nonce,
+		Message:   t.message,
+	}
+}
 
-	log.Println("Generate signature", string(m))
+// GenerateSignature signs the transaction for the chain it was created for.
+func (t *Transaction) GenerateSignature() *utils.Signature {
+	payload := t.SigningPayload()
+	log.Printf("Generate signature over %x", payload.Encode())
 
-	h := sha256.Sum256([]byte(m))
-	r, s, err := ecdsa.Sign(rand.Reader, t.senderPrivateKey, h[:])
+	signature, err := signing.Sign(t.senderPrivateKey, payload)
 	if err != nil {
 		fmt.Println("Signing signature failed: ", err)
 		return nil
 	}
-	return &utils.Signature{R: r, S: s}
+	return signature
 }
 
 func (t *Transaction) MarshalJSON() ([]byte, error) {
 	return json.Marshal(struct {
-		Message   string  `json:"message"`
-		Sender    string  `json:"senderBlockchainAddress"`
-		Recipient string  `json:"recipientBlockchainAddress"`
-		Value     float32 `json:"value"`
+		Message   string        `json:"message"`
+		Recipient string        `json:"recipientBlockchainAddress"`
+		Sender    string        `json:"senderBlockchainAddress"`
+		Value     amount.Amount `json:"value"`
+		Fee       amount.Amount `json:"fee"`
+		Nonce     uint64        `json:"nonce"`
 	}{
 		Message:   t.message,
-		Sender:    t.senderBlockchainAddress,
 		Recipient: t.recipientBlockchainAddress,
+		Sender:    t.senderBlockchainAddress,
 		Value:     t.value,
+		Fee:       t.fee,
+		Nonce:     t.nonce,
 	})
 }
 
@@ -152,7 +156,8 @@ func (tr *TransactionRequest) Validate() bool {
 		tr.RecipientBlockchainAddress == nil ||
 		tr.SenderPublicKey == nil ||
 		tr.Message == nil ||
-		tr.Value == nil {
+		tr.Value == nil ||
+		tr.Nonce == nil {
 		return false
 	}
 	return true